@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"plant-reminder/config"
+	"plant-reminder/container"
+	"plant-reminder/dto"
+	"plant-reminder/utils"
+)
+
+const commandUsage = `usage: plantie [flags] [command]
+
+Without a command the HTTP server is started. Run "plantie -h" for the flags.
+
+Commands:
+  migrate up | down [steps] | status
+  user create <email> [name] [timeZone]   create a user, the password is read from stdin
+  user delete <id|email>                   delete a user and all their data
+  user reset-password <id|email>           set a new password, read from stdin
+  user reset-2fa <id|email>                turn off two-factor authentication of a user
+  reminders due [within]                   list reminders due now, or within a duration such as 1h
+  reminders tick                           run one dispatch: claim due reminders and send their notifications
+  reminders reschedule                     recalculate the next trigger time of all reminders
+  push test <id|email>                     send a test notification to all devices of a user
+  keys generate [rsa|ed25519]              add a JWT signing key to the key directory, default ed25519
+`
+
+// runCommand runs an operator command instead of the server.
+func runCommand(cfg *config.Config, args []string) {
+	switch args[0] {
+	case "migrate":
+		runMigrateCommand(cfg, args[1:])
+	case "user":
+		runUserCommand(cfg, args[1:])
+	case "reminders":
+		runRemindersCommand(cfg, args[1:])
+	case "push":
+		runPushCommand(cfg, args[1:])
+	case "keys":
+		runKeysCommand(cfg, args[1:])
+	default:
+		fmt.Fprint(os.Stderr, commandUsage)
+		os.Exit(2)
+	}
+}
+
+// initCommandApp wires the services like the server does, without starting the
+// scheduler. Only commands that send push notifications get the configured
+// notifier; the others log instead, so they run without Firebase credentials.
+func initCommandApp(cfg *config.Config, sendsPush bool) *container.Application {
+	var notifier utils.Notifier = utils.NewLogNotifier()
+	if sendsPush {
+		if err := cfg.ValidateNotifier(); err != nil {
+			log.Fatalf("Invalid configuration:\n%v", err)
+		}
+		notifier = initNotifier(cfg.Notifier)
+	}
+
+	initDatabase(cfg)
+	checkSchema()
+	return container.NewApplication(cfg, notifier, initMailer(cfg.Mailer))
+}
+
+func runUserCommand(cfg *config.Config, args []string) {
+	if len(args) < 2 {
+		log.Fatal(commandUsage)
+	}
+
+	switch args[0] {
+	case "create":
+		request := dto.UserCreateRequest{Email: args[1], Password: readPassword()}
+		if len(args) > 2 {
+			request.Name = args[2]
+		}
+		if len(args) > 3 {
+			request.TimeZone = args[3]
+		}
+		if err := utils.Validate.Struct(request); err != nil {
+			log.Fatalf("Invalid user: %v", err)
+		}
+
+		app := initCommandApp(cfg, false)
+		authResponse, err := app.UserService.CreateUser(&request)
+		if err != nil {
+			log.Fatalf("Failed to create user: %v", err)
+		}
+		fmt.Printf("created user %d\n", authResponse.User.ID)
+	case "delete":
+		app := initCommandApp(cfg, false)
+		userID := resolveUserID(app, args[1])
+		if err := app.UserService.DeleteUser(userID); err != nil {
+			log.Fatalf("Failed to delete user: %v", err)
+		}
+		fmt.Printf("deleted user %d\n", userID)
+	case "reset-password":
+		password := readPassword()
+		if err := utils.Validate.Var(password, "required,min=6"); err != nil {
+			log.Fatal("Password must be at least 6 characters")
+		}
+
+		app := initCommandApp(cfg, false)
+		userID := resolveUserID(app, args[1])
+		if err := app.UserService.SetPassword(userID, password); err != nil {
+			log.Fatalf("Failed to reset password: %v", err)
+		}
+		fmt.Printf("password of user %d reset\n", userID)
+	case "reset-2fa":
+		app := initCommandApp(cfg, false)
+		userID := resolveUserID(app, args[1])
+		if err := app.UserService.ResetTwoFactor(userID); err != nil {
+			log.Fatalf("Failed to reset two-factor authentication: %v", err)
+		}
+		fmt.Printf("two-factor authentication of user %d turned off\n", userID)
+	default:
+		log.Fatal(commandUsage)
+	}
+}
+
+func runRemindersCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal(commandUsage)
+	}
+
+	switch args[0] {
+	case "due":
+		var within time.Duration
+		if len(args) > 1 {
+			d, err := time.ParseDuration(args[1])
+			if err != nil || d < 0 {
+				log.Fatalf("within must be a duration such as 30m or 2h, got %q", args[1])
+			}
+			within = d
+		}
+
+		app := initCommandApp(cfg, false)
+		reminders, err := app.ReminderService.GetDueReminders(time.Now().Add(within))
+		if err != nil {
+			log.Fatalf("Failed to list due reminders: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tPLANT\tTASK\tNEXT TRIGGER (UTC)\tSNOOZED UNTIL\tTIME ZONE")
+		for _, reminder := range reminders {
+			plant := ""
+			if reminder.Plant != nil {
+				plant = fmt.Sprintf("%d %s", reminder.Plant.ID, reminder.Plant.Name)
+			}
+			snoozedUntil := ""
+			if reminder.SnoozedUntil != nil {
+				snoozedUntil = reminder.SnoozedUntil.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", reminder.ID, plant, reminder.TaskType,
+				reminder.NextTriggerTime.UTC().Format(time.RFC3339), snoozedUntil, reminder.TimeZone)
+		}
+		w.Flush()
+	case "tick":
+		app := initCommandApp(cfg, true)
+		if err := app.ReminderService.RunDispatch(); err != nil {
+			log.Fatalf("Dispatch failed: %v", err)
+		}
+		fmt.Println("dispatch completed")
+	case "reschedule":
+		app := initCommandApp(cfg, false)
+		changed, err := app.ReminderService.RescheduleAllReminders()
+		if err != nil {
+			log.Fatalf("Failed to reschedule reminders after %d changes: %v", changed, err)
+		}
+		fmt.Printf("rescheduled %d reminder(s)\n", changed)
+	default:
+		log.Fatal(commandUsage)
+	}
+}
+
+func runPushCommand(cfg *config.Config, args []string) {
+	if len(args) < 2 || args[0] != "test" {
+		log.Fatal(commandUsage)
+	}
+
+	app := initCommandApp(cfg, true)
+	userID := resolveUserID(app, args[1])
+	if err := app.ReminderService.TestReminder(userID); err != nil {
+		log.Fatalf("Failed to send test notification: %v", err)
+	}
+	fmt.Printf("test notification sent to user %d\n", userID)
+}
+
+// runKeysCommand writes a new key named after the current time, so it sorts last and
+// becomes the signing key once the servers restart.
+func runKeysCommand(cfg *config.Config, args []string) {
+	if len(args) < 1 || args[0] != "generate" {
+		log.Fatal(commandUsage)
+	}
+	if cfg.Auth.JWTKeyDir == "" {
+		log.Fatal("JWT_KEY_DIR is not set")
+	}
+	keyType := utils.KeyTypeEd25519
+	if len(args) > 1 {
+		keyType = args[1]
+	}
+
+	key, err := utils.GenerateTokenKey(keyType)
+	if err != nil {
+		log.Fatalf("Failed to generate key: %v", err)
+	}
+	kid := time.Now().UTC().Format("20060102T150405Z")
+	path := filepath.Join(cfg.Auth.JWTKeyDir, kid+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatalf("Failed to create key file: %v", err)
+	}
+	_, err = file.Write(key)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A truncated key would fail LoadTokenKeys on the next start.
+		os.Remove(path)
+		log.Fatalf("Failed to write key file: %v", err)
+	}
+	fmt.Printf("wrote %s key %s to %s\n", keyType, kid, path)
+}
+
+// resolveUserID accepts a user ID or an email address.
+func resolveUserID(app *container.Application, idOrEmail string) int64 {
+	if id, err := strconv.ParseInt(idOrEmail, 10, 64); err == nil {
+		return id
+	}
+	user, err := app.UserService.GetUserByEmail(idOrEmail)
+	if err != nil {
+		log.Fatalf("User %s not found: %v", idOrEmail, err)
+	}
+	return user.ID
+}
+
+// readPassword reads one line from stdin, so passwords don't end up in the shell history.
+func readPassword() string {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("Failed to read password: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n")
+}
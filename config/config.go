@@ -2,45 +2,80 @@ package config
 
 import (
 	"fmt"
-	"os"
-	"time"
+	"strings"
 
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
 var DB *gorm.DB
 
-func InitDb() {
-	dsn := os.Getenv("DB_URL")
-	if dsn == "" {
-		panic("db url not found")
+// InitDb connects to the configured database: Postgres, or SQLite, in which case
+// the URL is the path of the database file.
+func InitDb(cfg DatabaseConfig) {
+	db, err := OpenDb(cfg)
+	if err != nil {
+		panic("Couldn't init database " + err.Error())
+	}
+
+	DB = db
+	fmt.Println("Connected to " + cfg.Driver)
+
+	if err := testConnection(db); err != nil {
+		panic("Database connection test failed: " + err.Error())
+	}
+}
+
+// OpenDb opens a connection pool for the configured driver.
+func OpenDb(cfg DatabaseConfig) (*gorm.DB, error) {
+	var dialector gorm.Dialector
+	switch cfg.Driver {
+	case DriverPostgres:
+		dialector = postgres.Open(cfg.URL)
+	case DriverSQLite:
+		dialector = sqlite.Open(sqliteDSN(cfg.URL))
+	default:
+		return nil, fmt.Errorf("unknown database driver: %s", cfg.Driver)
 	}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		PrepareStmt:                              false,
 		SkipDefaultTransaction:                   true,
 		DisableForeignKeyConstraintWhenMigrating: true,
 	})
 	if err != nil {
-		panic("Couldn't init database " + err.Error())
+		return nil, err
 	}
 
 	sqlDB, err := db.DB()
 	if err != nil {
-		panic("Failed to get database connection: " + err.Error())
+		return nil, fmt.Errorf("failed to get database connection: %w", err)
 	}
 
-	sqlDB.SetMaxOpenConns(10)
-	sqlDB.SetMaxIdleConns(5)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
-	DB = db
-	fmt.Println("Connected to Postgres")
+	if cfg.Driver == DriverSQLite {
+		// SQLite allows a single writer. One connection serializes all access
+		// instead of failing with "database is locked".
+		sqlDB.SetMaxOpenConns(1)
+	} else {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	return db, nil
+}
 
-	if err := testConnection(db); err != nil {
-		panic("Database connection test failed: " + err.Error())
+// sqliteDSN turns on the write-ahead log and a busy timeout for file databases.
+func sqliteDSN(path string) string {
+	if strings.Contains(path, "?") || strings.Contains(path, ":memory:") {
+		return path
 	}
+	return path + "?_journal_mode=WAL&_busy_timeout=5000"
 }
 
 func testConnection(db *gorm.DB) error {
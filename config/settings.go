@@ -0,0 +1,584 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"os"
+	"plant-reminder/constants"
+	"plant-reminder/oidc"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds all settings of the server and the operator commands. Settings are
+// read in this order, later sources overriding earlier ones: defaults, the YAML file
+// given by -config or CONFIG_FILE, environment variables, command line flags.
+type Config struct {
+	Port       string          `yaml:"port"`
+	AdminToken string          `yaml:"adminToken"`
+	Database   DatabaseConfig  `yaml:"database"`
+	Auth       AuthConfig      `yaml:"auth"`
+	Notifier   NotifierConfig  `yaml:"notifier"`
+	Mailer     MailerConfig    `yaml:"mailer"`
+	Scheduler  SchedulerConfig `yaml:"scheduler"`
+	RateLimit  RateLimitConfig `yaml:"rateLimit"`
+	// TrustedProxies are the addresses or CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header is believed. Without any, clients are identified by
+	// the address of the connection.
+	TrustedProxies StringList `yaml:"trustedProxies"`
+}
+
+type DatabaseConfig struct {
+	Driver          string        `yaml:"driver"`
+	URL             string        `yaml:"url"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+}
+
+type AuthConfig struct {
+	// JWTKey is the HS256 secret. With JWTKeyDir set it only verifies tokens issued
+	// before the switch to the keys of the directory.
+	JWTKey string `yaml:"jwtKey"`
+	// JWTKeyDir holds RSA or Ed25519 keys as <kid>.pem files. All of them verify
+	// tokens; new tokens are signed with JWTSigningKey, or the private key whose kid
+	// sorts last.
+	JWTKeyDir       string        `yaml:"jwtKeyDir"`
+	JWTSigningKey   string        `yaml:"jwtSigningKey"`
+	AccessTokenTTL  time.Duration `yaml:"accessTokenTTL"`
+	RefreshTokenTTL time.Duration `yaml:"refreshTokenTTL"`
+	BcryptCost      int           `yaml:"bcryptCost"`
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL time.Duration `yaml:"passwordResetTTL"`
+	// PasswordResetURL is the page of the app that sets the new password. The reset
+	// token is appended as the token query parameter.
+	PasswordResetURL string `yaml:"passwordResetURL"`
+	// EmailVerificationTTL is how long an email verification link stays valid.
+	EmailVerificationTTL time.Duration `yaml:"emailVerificationTTL"`
+	// EmailVerificationURL is the page of the app that confirms the email address.
+	// The verification token is appended as the token query parameter.
+	EmailVerificationURL string `yaml:"emailVerificationURL"`
+	// RequireVerifiedEmail stops push notifications to users who haven't verified
+	// their email address.
+	RequireVerifiedEmail bool `yaml:"requireVerifiedEmail"`
+	// LockoutThreshold is the number of failed logins in a row after which an
+	// account is locked for LockoutDuration. Each further failure doubles the lock,
+	// up to LockoutMaxDuration.
+	LockoutThreshold   int           `yaml:"lockoutThreshold"`
+	LockoutDuration    time.Duration `yaml:"lockoutDuration"`
+	LockoutMaxDuration time.Duration `yaml:"lockoutMaxDuration"`
+	// OIDCProviders are the OpenID Connect providers users can log in with. A
+	// provider's name is its part of the login URL.
+	OIDCProviders OIDCProviderList `yaml:"oidcProviders"`
+}
+
+const (
+	NotifierFCM = "fcm"
+	NotifierLog = "log"
+)
+
+type NotifierConfig struct {
+	Kind         string `yaml:"kind"`
+	FirebasePath string `yaml:"firebasePath"`
+}
+
+const (
+	MailerSMTP = "smtp"
+	MailerLog  = "log"
+	MailerFile = "file"
+)
+
+type MailerConfig struct {
+	Kind string `yaml:"kind"`
+	From string `yaml:"from"`
+	// Dir is where the file mailer writes emails to.
+	Dir          string `yaml:"dir"`
+	SMTPHost     string `yaml:"smtpHost"`
+	SMTPPort     int    `yaml:"smtpPort"`
+	SMTPUsername string `yaml:"smtpUsername"`
+	SMTPPassword string `yaml:"smtpPassword"`
+}
+
+const (
+	RateLimitMemory   = "memory"
+	RateLimitDatabase = "database"
+)
+
+// RateLimitConfig limits the public auth endpoints with token buckets: a bucket
+// holds up to Burst requests and regains one every Interval.
+type RateLimitConfig struct {
+	// Store is memory for buckets per instance, or database to share them between
+	// replicas.
+	Store           string        `yaml:"store"`
+	IPBurst         int           `yaml:"ipBurst"`
+	IPInterval      time.Duration `yaml:"ipInterval"`
+	AccountBurst    int           `yaml:"accountBurst"`
+	AccountInterval time.Duration `yaml:"accountInterval"`
+}
+
+type SchedulerConfig struct {
+	CatchUpPolicy constants.CatchUpPolicy `yaml:"catchUpPolicy"`
+	Workers       int                     `yaml:"workers"`
+	BatchSize     int                     `yaml:"batchSize"`
+	// Interval is how often due reminders are claimed.
+	Interval time.Duration `yaml:"interval"`
+	// OutboxInterval is how often the outbox worker looks for messages to send.
+	OutboxInterval time.Duration `yaml:"outboxInterval"`
+}
+
+// Default returns the settings used when nothing else is configured.
+func Default() *Config {
+	return &Config{
+		Port: "8080",
+		Database: DatabaseConfig{
+			Driver:          DriverPostgres,
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  3 * time.Hour,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+			BcryptCost:      14,
+
+			PasswordResetTTL:     time.Hour,
+			EmailVerificationTTL: 48 * time.Hour,
+
+			LockoutThreshold:   5,
+			LockoutDuration:    time.Minute,
+			LockoutMaxDuration: time.Hour,
+		},
+		Notifier: NotifierConfig{Kind: NotifierFCM},
+		Mailer: MailerConfig{
+			Kind:     MailerLog,
+			From:     "Plantie <no-reply@localhost>",
+			Dir:      "mail",
+			SMTPPort: 587,
+		},
+		Scheduler: SchedulerConfig{
+			CatchUpPolicy:  constants.CatchUpOnce,
+			Workers:        4,
+			BatchSize:      500,
+			Interval:       time.Minute,
+			OutboxInterval: 10 * time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			Store:           RateLimitMemory,
+			IPBurst:         20,
+			IPInterval:      3 * time.Second,
+			AccountBurst:    5,
+			AccountInterval: time.Minute,
+		},
+	}
+}
+
+// setting ties a flag to the environment variable and YAML key of the same field.
+type setting struct {
+	flag string
+	env  string
+	key  string
+}
+
+var settings = []setting{
+	{"port", "PORT", "port"},
+	{"admin-token", "ADMIN_TOKEN", "adminToken"},
+	{"db-driver", "DB_DRIVER", "database.driver"},
+	{"db-url", "DB_URL", "database.url"},
+	{"db-max-open-conns", "DB_MAX_OPEN_CONNS", "database.maxOpenConns"},
+	{"db-max-idle-conns", "DB_MAX_IDLE_CONNS", "database.maxIdleConns"},
+	{"db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", "database.connMaxLifetime"},
+	{"jwt-key", "JWT_KEY", "auth.jwtKey"},
+	{"jwt-key-dir", "JWT_KEY_DIR", "auth.jwtKeyDir"},
+	{"jwt-signing-key", "JWT_SIGNING_KEY", "auth.jwtSigningKey"},
+	{"access-token-ttl", "ACCESS_TOKEN_TTL", "auth.accessTokenTTL"},
+	{"refresh-token-ttl", "REFRESH_TOKEN_TTL", "auth.refreshTokenTTL"},
+	{"bcrypt-cost", "BCRYPT_COST", "auth.bcryptCost"},
+	{"password-reset-ttl", "PASSWORD_RESET_TTL", "auth.passwordResetTTL"},
+	{"password-reset-url", "PASSWORD_RESET_URL", "auth.passwordResetURL"},
+	{"email-verification-ttl", "EMAIL_VERIFICATION_TTL", "auth.emailVerificationTTL"},
+	{"email-verification-url", "EMAIL_VERIFICATION_URL", "auth.emailVerificationURL"},
+	{"require-verified-email", "REQUIRE_VERIFIED_EMAIL", "auth.requireVerifiedEmail"},
+	{"lockout-threshold", "LOCKOUT_THRESHOLD", "auth.lockoutThreshold"},
+	{"lockout-duration", "LOCKOUT_DURATION", "auth.lockoutDuration"},
+	{"lockout-max-duration", "LOCKOUT_MAX_DURATION", "auth.lockoutMaxDuration"},
+	{"oidc-providers", "OIDC_PROVIDERS", "auth.oidcProviders"},
+	{"notifier", "NOTIFIER", "notifier.kind"},
+	{"firebase-path", "FIREBASE_PATH", "notifier.firebasePath"},
+	{"mailer", "MAILER", "mailer.kind"},
+	{"mail-from", "MAIL_FROM", "mailer.from"},
+	{"mail-dir", "MAIL_DIR", "mailer.dir"},
+	{"smtp-host", "SMTP_HOST", "mailer.smtpHost"},
+	{"smtp-port", "SMTP_PORT", "mailer.smtpPort"},
+	{"smtp-username", "SMTP_USERNAME", "mailer.smtpUsername"},
+	{"smtp-password", "SMTP_PASSWORD", "mailer.smtpPassword"},
+	{"catch-up-policy", "CATCH_UP_POLICY", "scheduler.catchUpPolicy"},
+	{"dispatch-workers", "DISPATCH_WORKERS", "scheduler.workers"},
+	{"dispatch-batch-size", "DISPATCH_BATCH_SIZE", "scheduler.batchSize"},
+	{"scheduler-interval", "SCHEDULER_INTERVAL", "scheduler.interval"},
+	{"outbox-interval", "OUTBOX_INTERVAL", "scheduler.outboxInterval"},
+	{"rate-limit-store", "RATE_LIMIT_STORE", "rateLimit.store"},
+	{"rate-limit-ip-burst", "RATE_LIMIT_IP_BURST", "rateLimit.ipBurst"},
+	{"rate-limit-ip-interval", "RATE_LIMIT_IP_INTERVAL", "rateLimit.ipInterval"},
+	{"rate-limit-account-burst", "RATE_LIMIT_ACCOUNT_BURST", "rateLimit.accountBurst"},
+	{"rate-limit-account-interval", "RATE_LIMIT_ACCOUNT_INTERVAL", "rateLimit.accountInterval"},
+	{"trusted-proxies", "TRUSTED_PROXIES", "trustedProxies"},
+}
+
+func (c *Config) bindFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Port, "port", c.Port, "HTTP port")
+	fs.StringVar(&c.AdminToken, "admin-token", c.AdminToken, "shared secret for the /admin endpoints")
+	fs.StringVar(&c.Database.Driver, "db-driver", c.Database.Driver, "database driver: postgres or sqlite")
+	fs.StringVar(&c.Database.URL, "db-url", c.Database.URL, "Postgres connection string or SQLite file path")
+	fs.IntVar(&c.Database.MaxOpenConns, "db-max-open-conns", c.Database.MaxOpenConns, "maximum open database connections")
+	fs.IntVar(&c.Database.MaxIdleConns, "db-max-idle-conns", c.Database.MaxIdleConns, "maximum idle database connections")
+	fs.DurationVar(&c.Database.ConnMaxLifetime, "db-conn-max-lifetime", c.Database.ConnMaxLifetime, "maximum lifetime of a database connection")
+	fs.StringVar(&c.Auth.JWTKey, "jwt-key", c.Auth.JWTKey, "HS256 secret for JWT signing, or only for verifying older tokens with -jwt-key-dir")
+	fs.StringVar(&c.Auth.JWTKeyDir, "jwt-key-dir", c.Auth.JWTKeyDir, "directory of RSA or Ed25519 JWT keys named <kid>.pem")
+	fs.StringVar(&c.Auth.JWTSigningKey, "jwt-signing-key", c.Auth.JWTSigningKey, "kid of the key new JWTs are signed with, default the last one")
+	fs.DurationVar(&c.Auth.AccessTokenTTL, "access-token-ttl", c.Auth.AccessTokenTTL, "lifetime of access tokens")
+	fs.DurationVar(&c.Auth.RefreshTokenTTL, "refresh-token-ttl", c.Auth.RefreshTokenTTL, "lifetime of refresh tokens")
+	fs.IntVar(&c.Auth.BcryptCost, "bcrypt-cost", c.Auth.BcryptCost, "bcrypt cost of password hashes")
+	fs.DurationVar(&c.Auth.PasswordResetTTL, "password-reset-ttl", c.Auth.PasswordResetTTL, "how long password reset links stay valid")
+	fs.StringVar(&c.Auth.PasswordResetURL, "password-reset-url", c.Auth.PasswordResetURL, "URL of the app page that sets a new password")
+	fs.DurationVar(&c.Auth.EmailVerificationTTL, "email-verification-ttl", c.Auth.EmailVerificationTTL, "how long email verification links stay valid")
+	fs.StringVar(&c.Auth.EmailVerificationURL, "email-verification-url", c.Auth.EmailVerificationURL, "URL of the app page that confirms an email address")
+	fs.BoolVar(&c.Auth.RequireVerifiedEmail, "require-verified-email", c.Auth.RequireVerifiedEmail, "send no push notifications to users with an unverified email")
+	fs.IntVar(&c.Auth.LockoutThreshold, "lockout-threshold", c.Auth.LockoutThreshold, "failed logins in a row that lock an account")
+	fs.DurationVar(&c.Auth.LockoutDuration, "lockout-duration", c.Auth.LockoutDuration, "how long an account is locked at first")
+	fs.DurationVar(&c.Auth.LockoutMaxDuration, "lockout-max-duration", c.Auth.LockoutMaxDuration, "longest lock after repeated failed logins")
+	fs.Var(&c.Auth.OIDCProviders, "oidc-providers", `OpenID Connect providers as a JSON array of {"name", "issuer", "clientId", "clientSecret"}`)
+	fs.StringVar(&c.Notifier.Kind, "notifier", c.Notifier.Kind, "notifier: fcm or log")
+	fs.StringVar(&c.Notifier.FirebasePath, "firebase-path", c.Notifier.FirebasePath, "path to the Firebase service account JSON")
+	fs.StringVar(&c.Mailer.Kind, "mailer", c.Mailer.Kind, "mailer: smtp, log or file")
+	fs.StringVar(&c.Mailer.From, "mail-from", c.Mailer.From, "sender address of emails")
+	fs.StringVar(&c.Mailer.Dir, "mail-dir", c.Mailer.Dir, "directory the file mailer writes emails to")
+	fs.StringVar(&c.Mailer.SMTPHost, "smtp-host", c.Mailer.SMTPHost, "SMTP server host")
+	fs.IntVar(&c.Mailer.SMTPPort, "smtp-port", c.Mailer.SMTPPort, "SMTP server port")
+	fs.StringVar(&c.Mailer.SMTPUsername, "smtp-username", c.Mailer.SMTPUsername, "SMTP username, empty for no authentication")
+	fs.StringVar(&c.Mailer.SMTPPassword, "smtp-password", c.Mailer.SMTPPassword, "SMTP password")
+	fs.StringVar((*string)(&c.Scheduler.CatchUpPolicy), "catch-up-policy", string(c.Scheduler.CatchUpPolicy), "catch-up policy for missed reminders: once, summary or skip")
+	fs.IntVar(&c.Scheduler.Workers, "dispatch-workers", c.Scheduler.Workers, "number of concurrent push deliveries")
+	fs.IntVar(&c.Scheduler.BatchSize, "dispatch-batch-size", c.Scheduler.BatchSize, "reminders or outbox messages claimed per transaction")
+	fs.DurationVar(&c.Scheduler.Interval, "scheduler-interval", c.Scheduler.Interval, "how often due reminders are claimed")
+	fs.DurationVar(&c.Scheduler.OutboxInterval, "outbox-interval", c.Scheduler.OutboxInterval, "how often the notification outbox is processed")
+	fs.StringVar(&c.RateLimit.Store, "rate-limit-store", c.RateLimit.Store, "where rate limit buckets are kept: memory or database")
+	fs.IntVar(&c.RateLimit.IPBurst, "rate-limit-ip-burst", c.RateLimit.IPBurst, "auth requests a client IP may burst")
+	fs.DurationVar(&c.RateLimit.IPInterval, "rate-limit-ip-interval", c.RateLimit.IPInterval, "time a client IP regains one auth request in")
+	fs.IntVar(&c.RateLimit.AccountBurst, "rate-limit-account-burst", c.RateLimit.AccountBurst, "logins or emails an account may burst")
+	fs.DurationVar(&c.RateLimit.AccountInterval, "rate-limit-account-interval", c.RateLimit.AccountInterval, "time an account regains one login or email in")
+	fs.Var(&c.TrustedProxies, "trusted-proxies", "comma separated addresses or CIDR ranges of trusted reverse proxies")
+}
+
+// Load reads the configuration from args (without the program name), the optional
+// config file and the environment, and validates it. It returns the arguments left
+// after the flags, i.e. the command to run.
+func Load(args []string) (*Config, []string, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("plantie", flag.ContinueOnError)
+	path := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	cfg.bindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	// The flags were written to cfg already. Remember them to apply them again
+	// over the file and the environment.
+	fromFlags := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) { fromFlags[f.Name] = f.Value.String() })
+
+	if *path != "" {
+		if err := cfg.loadFile(*path); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+		if err := fs.Set(s.flag, value); err != nil {
+			return nil, nil, fmt.Errorf("%s: invalid value %q", s.env, value)
+		}
+	}
+	for name, value := range fromFlags {
+		if err := fs.Set(name, value); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Commands don't need Firebase credentials unless they send push notifications,
+	// those check them with ValidateNotifier.
+	if err := cfg.validate(len(fs.Args()) == 0); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks all settings and reports every invalid one. It normalizes the
+// catch-up policy.
+func (c *Config) Validate() error {
+	return c.validate(true)
+}
+
+// ValidateNotifier checks the credentials of the notifier, which Load skips for
+// commands.
+func (c *Config) ValidateNotifier() error {
+	var errs []error
+	c.validateNotifier(func(flagName string, format string, args ...any) {
+		errs = append(errs, settingError(flagName, format, args...))
+	})
+	return errors.Join(errs...)
+}
+
+func (c *Config) validateNotifier(fail func(flagName string, format string, args ...any)) {
+	if c.Notifier.Kind != NotifierFCM {
+		return
+	}
+	if c.Notifier.FirebasePath == "" {
+		fail("firebase-path", "is required for the %s notifier", NotifierFCM)
+	} else if _, err := os.Stat(c.Notifier.FirebasePath); err != nil {
+		fail("firebase-path", "%v", err)
+	}
+}
+
+// settingError names the setting of flagName with its config key and environment
+// variable.
+func settingError(flagName string, format string, args ...any) error {
+	for _, s := range settings {
+		if s.flag == flagName {
+			return fmt.Errorf("%s (%s): %s", s.key, s.env, fmt.Sprintf(format, args...))
+		}
+	}
+	return fmt.Errorf("%s: %s", flagName, fmt.Sprintf(format, args...))
+}
+
+func (c *Config) validate(withNotifier bool) error {
+	var errs []error
+	fail := func(flagName string, format string, args ...any) {
+		errs = append(errs, settingError(flagName, format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("port", "must be a port number, got %q", c.Port)
+	}
+
+	if c.Database.Driver != DriverPostgres && c.Database.Driver != DriverSQLite {
+		fail("db-driver", "must be %s or %s, got %q", DriverPostgres, DriverSQLite, c.Database.Driver)
+	}
+	if c.Database.URL == "" {
+		fail("db-url", "is required")
+	}
+	if c.Database.MaxOpenConns < 1 {
+		fail("db-max-open-conns", "must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		fail("db-max-idle-conns", "must be between 0 and the maximum open connections")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		fail("db-conn-max-lifetime", "must not be negative")
+	}
+
+	if c.Auth.JWTKey == "" && c.Auth.JWTKeyDir == "" {
+		fail("jwt-key", "is required without a JWT key directory")
+	}
+	if c.Auth.JWTKeyDir != "" {
+		if info, err := os.Stat(c.Auth.JWTKeyDir); err != nil {
+			fail("jwt-key-dir", "%v", err)
+		} else if !info.IsDir() {
+			fail("jwt-key-dir", "%s is not a directory", c.Auth.JWTKeyDir)
+		}
+	} else if c.Auth.JWTSigningKey != "" {
+		fail("jwt-signing-key", "needs a JWT key directory")
+	}
+	if c.Auth.AccessTokenTTL <= 0 {
+		fail("access-token-ttl", "must be positive")
+	}
+	if c.Auth.RefreshTokenTTL <= c.Auth.AccessTokenTTL {
+		fail("refresh-token-ttl", "must be longer than the access token TTL")
+	}
+	if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+		fail("bcrypt-cost", "must be between 4 and 31, got %d", c.Auth.BcryptCost)
+	}
+	if c.Auth.PasswordResetTTL < time.Minute {
+		fail("password-reset-ttl", "must be at least 1m")
+	}
+	if c.Auth.PasswordResetURL != "" && !isAbsoluteURL(c.Auth.PasswordResetURL) {
+		fail("password-reset-url", "must be an absolute URL, got %q", c.Auth.PasswordResetURL)
+	}
+	if c.Auth.EmailVerificationTTL < time.Minute {
+		fail("email-verification-ttl", "must be at least 1m")
+	}
+	if c.Auth.EmailVerificationURL != "" && !isAbsoluteURL(c.Auth.EmailVerificationURL) {
+		fail("email-verification-url", "must be an absolute URL, got %q", c.Auth.EmailVerificationURL)
+	}
+
+	if c.Auth.LockoutThreshold < 1 {
+		fail("lockout-threshold", "must be at least 1")
+	}
+	if c.Auth.LockoutDuration < time.Second {
+		fail("lockout-duration", "must be at least 1s")
+	}
+	if c.Auth.LockoutMaxDuration < c.Auth.LockoutDuration {
+		fail("lockout-max-duration", "must not be shorter than the lockout duration")
+	}
+
+	names := make(map[string]bool)
+	for i, provider := range c.Auth.OIDCProviders {
+		if !providerName.MatchString(provider.Name) || names[provider.Name] {
+			fail("oidc-providers", "provider %d needs a unique name of lowercase letters, digits and dashes, got %q", i+1, provider.Name)
+		}
+		names[provider.Name] = true
+		if !isAbsoluteURL(provider.Issuer) {
+			fail("oidc-providers", "issuer of %s must be an absolute URL, got %q", provider.Name, provider.Issuer)
+		}
+		if provider.ClientID == "" {
+			fail("oidc-providers", "client ID of %s is required", provider.Name)
+		}
+	}
+
+	if c.Notifier.Kind != NotifierFCM && c.Notifier.Kind != NotifierLog {
+		fail("notifier", "must be %s or %s, got %q", NotifierFCM, NotifierLog, c.Notifier.Kind)
+	} else if withNotifier {
+		c.validateNotifier(fail)
+	}
+
+	if _, err := mail.ParseAddress(c.Mailer.From); err != nil {
+		fail("mail-from", "must be an email address, got %q", c.Mailer.From)
+	}
+	switch c.Mailer.Kind {
+	case MailerSMTP:
+		if c.Mailer.SMTPHost == "" {
+			fail("smtp-host", "is required for the %s mailer", MailerSMTP)
+		}
+		if c.Mailer.SMTPPort < 1 || c.Mailer.SMTPPort > 65535 {
+			fail("smtp-port", "must be a port number, got %d", c.Mailer.SMTPPort)
+		}
+	case MailerFile:
+		if c.Mailer.Dir == "" {
+			fail("mail-dir", "is required for the %s mailer", MailerFile)
+		}
+	case MailerLog:
+	default:
+		fail("mailer", "must be %s, %s or %s, got %q", MailerSMTP, MailerLog, MailerFile, c.Mailer.Kind)
+	}
+
+	policy, err := constants.ParseCatchUpPolicy(string(c.Scheduler.CatchUpPolicy))
+	if err != nil {
+		fail("catch-up-policy", "%v", err)
+	}
+	c.Scheduler.CatchUpPolicy = policy
+	if c.Scheduler.Workers < 1 {
+		fail("dispatch-workers", "must be at least 1")
+	}
+	if c.Scheduler.BatchSize < 1 {
+		fail("dispatch-batch-size", "must be at least 1")
+	}
+	if c.Scheduler.Interval < time.Second {
+		fail("scheduler-interval", "must be at least 1s")
+	}
+	if c.Scheduler.OutboxInterval < time.Second {
+		fail("outbox-interval", "must be at least 1s")
+	}
+
+	if c.RateLimit.Store != RateLimitMemory && c.RateLimit.Store != RateLimitDatabase {
+		fail("rate-limit-store", "must be %s or %s, got %q", RateLimitMemory, RateLimitDatabase, c.RateLimit.Store)
+	}
+	if c.RateLimit.IPBurst < 1 {
+		fail("rate-limit-ip-burst", "must be at least 1")
+	}
+	if c.RateLimit.IPInterval <= 0 {
+		fail("rate-limit-ip-interval", "must be positive")
+	}
+	if c.RateLimit.AccountBurst < 1 {
+		fail("rate-limit-account-burst", "must be at least 1")
+	}
+	if c.RateLimit.AccountInterval <= 0 {
+		fail("rate-limit-account-interval", "must be positive")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if !isIPOrCIDR(proxy) {
+			fail("trusted-proxies", "must be IP addresses or CIDR ranges, got %q", proxy)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.IsAbs()
+}
+
+func isIPOrCIDR(raw string) bool {
+	if _, err := netip.ParseAddr(raw); err == nil {
+		return true
+	}
+	_, err := netip.ParsePrefix(raw)
+	return err == nil
+}
+
+var providerName = regexp.MustCompile(`^[a-z0-9-]+$`)
+
+// OIDCProviderList is given as a JSON array in flags and the environment and as a
+// sequence in the config file.
+type OIDCProviderList []oidc.Config
+
+func (l *OIDCProviderList) String() string {
+	if len(*l) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(*l)
+	return string(data)
+}
+
+func (l *OIDCProviderList) Set(value string) error {
+	var providers OIDCProviderList
+	if err := json.Unmarshal([]byte(value), &providers); err != nil {
+		return err
+	}
+	*l = providers
+	return nil
+}
+
+// StringList is a list setting, given as comma separated values in flags and the
+// environment and as a sequence in the config file.
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	*l = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
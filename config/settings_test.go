@@ -0,0 +1,193 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"plant-reminder/constants"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets all configuration variables for the duration of the test.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+	}
+	t.Setenv("CONFIG_FILE", "")
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plantie.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, `
+port: "9000"
+database:
+  driver: sqlite
+  url: file.db
+auth:
+  jwtKey: from-file
+  accessTokenTTL: 15m
+notifier:
+  kind: log
+scheduler:
+  catchUpPolicy: summary
+  workers: 2
+`)
+	t.Setenv("JWT_KEY", "from-env")
+	t.Setenv("DISPATCH_WORKERS", "6")
+
+	cfg, args, err := Load([]string{"-config", path, "-dispatch-workers", "8", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if cfg.Port != "9000" || cfg.Database.Driver != DriverSQLite || cfg.Auth.AccessTokenTTL != 15*time.Minute {
+		t.Errorf("Expected values from the file, got %+v", cfg)
+	}
+	if cfg.Auth.JWTKey != "from-env" {
+		t.Errorf("Expected the environment to override the file, got %q", cfg.Auth.JWTKey)
+	}
+	if cfg.Scheduler.Workers != 8 {
+		t.Errorf("Expected the flag to override the environment, got %d", cfg.Scheduler.Workers)
+	}
+	if cfg.Scheduler.CatchUpPolicy != constants.CatchUpSummary || cfg.Scheduler.BatchSize != 500 {
+		t.Errorf("Unexpected scheduler settings: %+v", cfg.Scheduler)
+	}
+	if strings.Join(args, " ") != "migrate up" {
+		t.Errorf("Expected the command after the flags, got %v", args)
+	}
+}
+
+func TestLoad_ReportsAllInvalidSettings(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_DRIVER", "mysql")
+	t.Setenv("BCRYPT_COST", "40")
+	t.Setenv("MAILER", "smtp")
+
+	_, _, err := Load(nil)
+	if err == nil {
+		t.Fatal("Expected a validation error")
+	}
+	for _, want := range []string{"DB_DRIVER", "DB_URL", "JWT_KEY", "BCRYPT_COST", "FIREBASE_PATH", "SMTP_HOST"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected the error to mention %s, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoad_CommandsSkipNotifierCredentials(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("DB_URL", "file.db")
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("JWT_KEY", "secret")
+
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "FIREBASE_PATH") {
+		t.Errorf("Expected the server to need FIREBASE_PATH, got %v", err)
+	}
+
+	cfg, _, err := Load([]string{"user", "delete", "1"})
+	if err != nil {
+		t.Fatalf("Expected a command to load without FIREBASE_PATH, got %v", err)
+	}
+	if err := cfg.ValidateNotifier(); err == nil || !strings.Contains(err.Error(), "FIREBASE_PATH") {
+		t.Errorf("Expected ValidateNotifier to need FIREBASE_PATH, got %v", err)
+	}
+}
+
+func TestLoad_RejectsMalformedValues(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("ACCESS_TOKEN_TTL", "3 hours")
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "ACCESS_TOKEN_TTL") {
+		t.Errorf("Expected an error for ACCESS_TOKEN_TTL, got %v", err)
+	}
+
+	clearEnv(t)
+	path := writeConfigFile(t, "database:\n  uri: x\n")
+	if _, _, err := Load([]string{"-config", path}); err == nil {
+		t.Error("Expected an error for an unknown key in the config file")
+	}
+}
+
+func TestLoad_TrustedProxies(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, `
+database:
+  driver: sqlite
+  url: file.db
+auth:
+  jwtKey: key
+notifier:
+  kind: log
+trustedProxies: [10.0.0.1]
+`)
+	cfg, _, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if strings.Join(cfg.TrustedProxies, " ") != "10.0.0.1" {
+		t.Errorf("Expected the proxies from the file, got %v", cfg.TrustedProxies)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1")
+	cfg, _, err = Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if strings.Join(cfg.TrustedProxies, " ") != "10.0.0.0/8 192.168.1.1" {
+		t.Errorf("Expected the environment to replace the proxies, got %v", cfg.TrustedProxies)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "proxy.local")
+	if _, _, err := Load([]string{"-config", path}); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Errorf("Expected an error for TRUSTED_PROXIES, got %v", err)
+	}
+}
+
+func TestLoad_OIDCProviders(t *testing.T) {
+	clearEnv(t)
+	path := writeConfigFile(t, `
+database:
+  driver: sqlite
+  url: file.db
+auth:
+  jwtKey: key
+  oidcProviders:
+    - name: google
+      issuer: https://accounts.google.com
+      clientId: client
+      clientSecret: secret
+notifier:
+  kind: log
+`)
+	cfg, _, err := Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Auth.OIDCProviders) != 1 || cfg.Auth.OIDCProviders[0].ClientSecret != "secret" {
+		t.Errorf("Expected the provider from the file, got %+v", cfg.Auth.OIDCProviders)
+	}
+
+	t.Setenv("OIDC_PROVIDERS", `[{"name": "apple", "issuer": "https://appleid.apple.com", "clientId": "app"}]`)
+	cfg, _, err = Load([]string{"-config", path})
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cfg.Auth.OIDCProviders) != 1 || cfg.Auth.OIDCProviders[0].Name != "apple" {
+		t.Errorf("Expected the environment to replace the providers, got %+v", cfg.Auth.OIDCProviders)
+	}
+
+	t.Setenv("OIDC_PROVIDERS", `[{"name": "apple", "issuer": "appleid.apple.com", "clientId": "app"}]`)
+	if _, _, err := Load([]string{"-config", path}); err == nil || !strings.Contains(err.Error(), "OIDC_PROVIDERS") {
+		t.Errorf("Expected an error for OIDC_PROVIDERS, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package constants
+
+// APIKeyScope is a permission granted to an API key. Write scopes don't include
+// the read scope of the same data.
+type APIKeyScope string
+
+const (
+	ScopePlantsRead     APIKeyScope = "plants:read"
+	ScopePlantsWrite    APIKeyScope = "plants:write"
+	ScopeRemindersRead  APIKeyScope = "reminders:read"
+	ScopeRemindersWrite APIKeyScope = "reminders:write"
+)
+
+func (s APIKeyScope) IsValid() bool {
+	switch s {
+	case ScopePlantsRead, ScopePlantsWrite, ScopeRemindersRead, ScopeRemindersWrite:
+		return true
+	}
+	return false
+}
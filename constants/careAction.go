@@ -0,0 +1,10 @@
+package constants
+
+type CareAction string
+
+const (
+	CareActionDone    CareAction = "done"
+	CareActionSkipped CareAction = "skipped"
+	// CareActionMissed is recorded by the scheduler for occurrences that were never notified on time.
+	CareActionMissed CareAction = "missed"
+)
@@ -0,0 +1,28 @@
+package constants
+
+import "fmt"
+
+// CatchUpPolicy decides what happens to a reminder whose occurrences were missed,
+// e.g. because the server was down when they were due.
+type CatchUpPolicy string
+
+const (
+	// CatchUpOnce sends a single regular notification for all missed occurrences.
+	CatchUpOnce CatchUpPolicy = "once"
+	// CatchUpSummary sends a single notification that tells how many occurrences were missed.
+	CatchUpSummary CatchUpPolicy = "summary"
+	// CatchUpSkip sends nothing and only records the missed occurrences.
+	CatchUpSkip CatchUpPolicy = "skip"
+)
+
+// ParseCatchUpPolicy parses a policy name. An empty name means CatchUpOnce.
+func ParseCatchUpPolicy(name string) (CatchUpPolicy, error) {
+	switch policy := CatchUpPolicy(name); policy {
+	case "":
+		return CatchUpOnce, nil
+	case CatchUpOnce, CatchUpSummary, CatchUpSkip:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid catch-up policy: %s", name)
+	}
+}
@@ -0,0 +1,11 @@
+package constants
+
+type OutboxStatus string
+
+const (
+	// OutboxPending messages wait for their first or next delivery attempt.
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	// OutboxDead messages failed permanently or ran out of attempts and are not retried.
+	OutboxDead OutboxStatus = "dead"
+)
@@ -12,10 +12,20 @@ const (
 	RepeatDaily RepeatType = iota
 	RepeatWeekly
 	RepeatMonthly
+	RepeatEveryNDays
+	RepeatEveryNWeeks
+	RepeatCron
 )
 
+const maxInterval = 365
+
 func (r RepeatType) String() string {
-	return [...]string{"daily", "weekly", "monthly"}[r]
+	return [...]string{"daily", "weekly", "monthly", "everyNDays", "everyNWeeks", "cron"}[r]
+}
+
+// IsInterval reports whether the repeat type fires every Interval days or weeks.
+func (r RepeatType) IsInterval() bool {
+	return r == RepeatEveryNDays || r == RepeatEveryNWeeks
 }
 
 func (r *RepeatType) UnmarshalJSON(b []byte) error {
@@ -28,6 +38,12 @@ func (r *RepeatType) UnmarshalJSON(b []byte) error {
 			*r = RepeatWeekly
 		case "monthly":
 			*r = RepeatMonthly
+		case "everyNDays":
+			*r = RepeatEveryNDays
+		case "everyNWeeks":
+			*r = RepeatEveryNWeeks
+		case "cron":
+			*r = RepeatCron
 		default:
 			return fmt.Errorf("invalid repeatType: %s", s)
 		}
@@ -45,34 +61,80 @@ func (r RepeatType) MarshalJSON() ([]byte, error) {
 	return json.Marshal(r.String())
 }
 
-func ValidateReminderFields(repeatType RepeatType, dayOfWeek, dayOfMonth *int16) error {
-	switch repeatType {
-	case RepeatDaily:
-		if dayOfWeek != nil {
-			return errors.New("dayOfWeek should not be set for daily reminders")
+// ScheduleFields are the reminder fields whose presence depends on the repeat type.
+type ScheduleFields struct {
+	TimeOfDay      string
+	DayOfWeek      *int16
+	DaysOfWeek     []int16
+	DayOfMonth     *int16
+	Interval       *int16
+	CronExpression string
+}
+
+func ValidateReminderFields(repeatType RepeatType, fields ScheduleFields) error {
+	if repeatType != RepeatWeekly && (fields.DayOfWeek != nil || len(fields.DaysOfWeek) != 0) {
+		return fmt.Errorf("dayOfWeek should not be set for %s reminders", repeatType)
+	}
+	if repeatType != RepeatMonthly && fields.DayOfMonth != nil {
+		return fmt.Errorf("dayOfMonth should not be set for %s reminders", repeatType)
+	}
+	if !repeatType.IsInterval() && fields.Interval != nil {
+		return fmt.Errorf("interval should not be set for %s reminders", repeatType)
+	}
+	if repeatType != RepeatCron {
+		if fields.CronExpression != "" {
+			return fmt.Errorf("cronExpression should not be set for %s reminders", repeatType)
 		}
-		if dayOfMonth != nil {
-			return errors.New("dayOfMonth should not be set for daily reminders")
+		if fields.TimeOfDay == "" {
+			return errors.New("timeOfDay is required")
 		}
+	}
+
+	switch repeatType {
+	case RepeatDaily:
+		// timeOfDay is all a daily reminder needs.
 
 	case RepeatWeekly:
-		if dayOfWeek == nil {
-			return errors.New("dayOfWeek is required for weekly reminders")
+		if fields.DayOfWeek == nil && len(fields.DaysOfWeek) == 0 {
+			return errors.New("dayOfWeek or daysOfWeek is required for weekly reminders")
+		}
+		if fields.DayOfWeek != nil && len(fields.DaysOfWeek) != 0 {
+			return errors.New("only one of dayOfWeek and daysOfWeek can be set")
 		}
-		if dayOfMonth != nil {
-			return errors.New("dayOfMonth should not be set for weekly reminders")
+		seen := map[int16]bool{}
+		for _, day := range fields.DaysOfWeek {
+			if day < 0 || day > 6 {
+				return errors.New("daysOfWeek must be between 0 and 6")
+			}
+			if seen[day] {
+				return errors.New("daysOfWeek must not contain duplicates")
+			}
+			seen[day] = true
 		}
 
 	case RepeatMonthly:
-		if dayOfMonth == nil {
+		if fields.DayOfMonth == nil {
 			return errors.New("dayOfMonth is required for monthly reminders")
 		}
-		if dayOfWeek != nil {
-			return errors.New("dayOfWeek should not be set for monthly reminders")
+
+	case RepeatEveryNDays, RepeatEveryNWeeks:
+		if fields.Interval == nil {
+			return fmt.Errorf("interval is required for %s reminders", repeatType)
+		}
+		if *fields.Interval < 1 || *fields.Interval > maxInterval {
+			return fmt.Errorf("interval must be between 1 and %d", maxInterval)
+		}
+
+	case RepeatCron:
+		if fields.TimeOfDay != "" {
+			return errors.New("timeOfDay should not be set for cron reminders")
+		}
+		if fields.CronExpression == "" {
+			return errors.New("cronExpression is required for cron reminders")
 		}
 
 	default:
-		return fmt.Errorf("invalid repeatType: %s", repeatType)
+		return fmt.Errorf("invalid repeatType: %d", repeatType)
 	}
 
 	return nil
@@ -0,0 +1,45 @@
+package constants
+
+import (
+	"errors"
+	"fmt"
+)
+
+type TaskType string
+
+const (
+	TaskWater     TaskType = "water"
+	TaskFertilize TaskType = "fertilize"
+	TaskMist      TaskType = "mist"
+	TaskRepot     TaskType = "repot"
+	TaskRotate    TaskType = "rotate"
+	TaskPrune     TaskType = "prune"
+	TaskCustom    TaskType = "custom"
+)
+
+var validTaskTypes = []TaskType{
+	TaskWater, TaskFertilize, TaskMist, TaskRepot, TaskRotate, TaskPrune, TaskCustom,
+}
+
+func (t TaskType) IsValid() bool {
+	for _, valid := range validTaskTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateTask checks the task type and its label. Only custom tasks carry a label.
+func ValidateTask(taskType TaskType, label string) error {
+	if !taskType.IsValid() {
+		return fmt.Errorf("invalid taskType: %s", taskType)
+	}
+	if taskType == TaskCustom && label == "" {
+		return errors.New("taskLabel is required for custom tasks")
+	}
+	if taskType != TaskCustom && label != "" {
+		return errors.New("taskLabel can only be set for custom tasks")
+	}
+	return nil
+}
@@ -3,39 +3,106 @@ package container
 import (
 	"plant-reminder/config"
 	"plant-reminder/controllers"
+	"plant-reminder/oidc"
+	"plant-reminder/ratelimit"
+	"plant-reminder/repository"
 	"plant-reminder/service"
+	"plant-reminder/utils"
 )
 
 type Application struct {
+	Config *config.Config
+
 	PlantService    *service.PlantService
 	UserService     *service.UserService
 	ReminderService *service.ReminderService
+	CareService     *service.CareService
+	OutboxService   *service.OutboxService
 
 	HealthController   *controllers.HealthController
 	PlantController    *controllers.PlantController
 	UserController     *controllers.UserController
 	ReminderController *controllers.ReminderController
+	CareController     *controllers.CareController
+	AdminController    *controllers.AdminController
+	JWKSController     *controllers.JWKSController
+
+	// IPLimiter throttles the public auth endpoints per client IP, AccountLimiter
+	// logins per account and MailLimiter the emails sent to an account.
+	IPLimiter      *ratelimit.Limiter
+	AccountLimiter *ratelimit.Limiter
+	MailLimiter    *ratelimit.Limiter
 }
 
-func NewApplication() *Application {
+func NewApplication(cfg *config.Config, notifier utils.Notifier, mailer utils.Mailer) *Application {
 	db := config.DB
-	plantService := service.NewPlantService(db)
-	userService := service.NewUserService(db)
-	reminderService := service.NewReminderService(plantService, db)
+	dispatchConfig := service.DispatchConfig{
+		CatchUpPolicy:  cfg.Scheduler.CatchUpPolicy,
+		Workers:        cfg.Scheduler.Workers,
+		BatchSize:      cfg.Scheduler.BatchSize,
+		CheckInterval:  cfg.Scheduler.Interval,
+		OutboxInterval: cfg.Scheduler.OutboxInterval,
+
+		VerifiedUsersOnly: cfg.Auth.RequireVerifiedEmail,
+	}
+	accountConfig := service.AccountConfig{
+		PasswordResetTTL:     cfg.Auth.PasswordResetTTL,
+		PasswordResetURL:     cfg.Auth.PasswordResetURL,
+		EmailVerificationTTL: cfg.Auth.EmailVerificationTTL,
+		EmailVerificationURL: cfg.Auth.EmailVerificationURL,
+		LockoutThreshold:     cfg.Auth.LockoutThreshold,
+		LockoutDuration:      cfg.Auth.LockoutDuration,
+		LockoutMaxDuration:   cfg.Auth.LockoutMaxDuration,
+		OIDCProviders:        make(map[string]service.OIDCProvider),
+	}
+	for _, provider := range cfg.Auth.OIDCProviders {
+		accountConfig.OIDCProviders[provider.Name] = oidc.NewProvider(provider, nil)
+	}
+	userRepository := repository.NewUserRepository(db)
+	plantRepository := repository.NewPlantRepository(db)
+	reminderRepository := repository.NewReminderRepository(db)
+
+	plantService := service.NewPlantService(plantRepository)
+	outboxService := service.NewOutboxService(repository.NewOutboxRepository(db), userRepository, notifier, dispatchConfig)
+	reminderService := service.NewReminderService(plantService, outboxService, reminderRepository, userRepository, dispatchConfig)
+	userService := service.NewUserService(reminderService, mailer, userRepository, accountConfig)
+	careService := service.NewCareService(plantService, reminderService, repository.NewCareEventRepository(db), reminderRepository)
+
+	var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.RateLimit.Store == config.RateLimitDatabase {
+		rateLimitStore = ratelimit.NewDBStore(db)
+	}
+	ipLimiter := ratelimit.NewLimiter(rateLimitStore, "ip", cfg.RateLimit.IPBurst, cfg.RateLimit.IPInterval)
+	accountLimiter := ratelimit.NewLimiter(rateLimitStore, "account", cfg.RateLimit.AccountBurst, cfg.RateLimit.AccountInterval)
+	mailLimiter := ratelimit.NewLimiter(rateLimitStore, "mail", cfg.RateLimit.AccountBurst, cfg.RateLimit.AccountInterval)
 
 	healthController := controllers.NewHealthController()
 	plantController := controllers.NewPlantController(plantService)
 	userController := controllers.NewUserController(userService)
 	reminderController := controllers.NewReminderController(reminderService)
+	careController := controllers.NewCareController(careService)
+	adminController := controllers.NewAdminController(outboxService)
+	jwksController := controllers.NewJWKSController()
 
 	return &Application{
+		Config: cfg,
+
 		PlantService:    plantService,
 		UserService:     userService,
 		ReminderService: reminderService,
+		CareService:     careService,
+		OutboxService:   outboxService,
 
 		HealthController:   healthController,
 		PlantController:    plantController,
 		UserController:     userController,
 		ReminderController: reminderController,
+		CareController:     careController,
+		AdminController:    adminController,
+		JWKSController:     jwksController,
+
+		IPLimiter:      ipLimiter,
+		AccountLimiter: accountLimiter,
+		MailLimiter:    mailLimiter,
 	}
 }
@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"plant-reminder/constants"
+	"plant-reminder/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminController struct {
+	outboxService service.OutboxServiceInterface
+}
+
+func NewAdminController(outboxService service.OutboxServiceInterface) *AdminController {
+	return &AdminController{
+		outboxService: outboxService,
+	}
+}
+
+func (ac *AdminController) GetStuckNotifications(ctx *gin.Context) {
+	status := constants.OutboxStatus(ctx.Query("status"))
+
+	messages, err := ac.outboxService.GetStuckMessages(status)
+	if err != nil {
+		log.Printf("GetStuckNotifications: failed to get outbox messages: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"messages": messages})
+}
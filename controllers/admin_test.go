@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"plant-reminder/constants"
+	"plant-reminder/dto"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MockOutboxService struct {
+	GetStuckMessagesFunc func(constants.OutboxStatus) ([]dto.OutboxMessageResponse, error)
+}
+
+func (m *MockOutboxService) GetStuckMessages(status constants.OutboxStatus) ([]dto.OutboxMessageResponse, error) {
+	if m.GetStuckMessagesFunc != nil {
+		return m.GetStuckMessagesFunc(status)
+	}
+	return nil, nil
+}
+
+func setupAdminController(mockService *MockOutboxService) (*AdminController, *gin.Engine) {
+	router := setupTestRouter()
+	controller := &AdminController{outboxService: mockService}
+	return controller, router
+}
+
+func TestAdminController_GetStuckNotifications_Success(t *testing.T) {
+	mockService := &MockOutboxService{}
+	controller, router := setupAdminController(mockService)
+
+	mockService.GetStuckMessagesFunc = func(status constants.OutboxStatus) ([]dto.OutboxMessageResponse, error) {
+		if status != constants.OutboxDead {
+			t.Errorf("Expected status 'dead', got %q", status)
+		}
+		return []dto.OutboxMessageResponse{
+			{ID: 1, ReminderID: 2, Status: constants.OutboxDead, Attempts: 8, LastError: "push delivery failed: transient_failure"},
+		}, nil
+	}
+
+	router.GET("/admin/outbox", controller.GetStuckNotifications)
+
+	req, _ := http.NewRequest("GET", "/admin/outbox?status=dead", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string][]dto.OutboxMessageResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response["messages"]) != 1 || response["messages"][0].Attempts != 8 {
+		t.Errorf("Unexpected messages: %+v", response["messages"])
+	}
+}
+
+func TestAdminController_GetStuckNotifications_InvalidStatus(t *testing.T) {
+	mockService := &MockOutboxService{}
+	controller, router := setupAdminController(mockService)
+
+	mockService.GetStuckMessagesFunc = func(status constants.OutboxStatus) ([]dto.OutboxMessageResponse, error) {
+		return nil, errors.New("status must be pending or dead")
+	}
+
+	router.GET("/admin/outbox", controller.GetStuckNotifications)
+
+	req, _ := http.NewRequest("GET", "/admin/outbox?status=sent", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"plant-reminder/dto"
+	"plant-reminder/service"
+	"plant-reminder/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CareController struct {
+	careService service.CareServiceInterface
+}
+
+func NewCareController(careService service.CareServiceInterface) *CareController {
+	return &CareController{
+		careService: careService,
+	}
+}
+
+func (cc *CareController) CompleteReminder(ctx *gin.Context) {
+	cc.recordEvent(ctx, "CompleteReminder", cc.careService.CompleteReminder)
+}
+
+func (cc *CareController) SkipReminder(ctx *gin.Context) {
+	cc.recordEvent(ctx, "SkipReminder", cc.careService.SkipReminder)
+}
+
+func (cc *CareController) GetPlantHistory(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	plantID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("GetPlantHistory: invalid plant id: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid plant ID"})
+		return
+	}
+
+	history, err := cc.careService.GetPlantHistory(plantID, userID)
+	if errors.Is(err, service.ErrPlantNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("GetPlantHistory: failed to get history: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get history"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+func (cc *CareController) GetOverdueTasks(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+
+	tasks, err := cc.careService.GetOverdueTasks(userID)
+	if err != nil {
+		log.Printf("GetOverdueTasks: failed to get overdue tasks: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get overdue tasks"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"overdue": tasks})
+}
+
+type recordFunc func(*dto.CareEventRequest, int64, int64, int64) (*dto.CareEventResponse, error)
+
+func (cc *CareController) recordEvent(ctx *gin.Context, name string, record recordFunc) {
+	userID := ctx.GetInt64("userID")
+	plantID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid plant id: %v", name, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid plant ID"})
+		return
+	}
+	reminderID, err := strconv.ParseInt(ctx.Param("reminderId"), 10, 64)
+	if err != nil {
+		log.Printf("%s: invalid reminder id: %v", name, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid reminder ID"})
+		return
+	}
+
+	// The body is optional, an empty request records the event with the current time.
+	var request dto.CareEventRequest
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+			log.Printf("%s: failed to bind JSON: %v", name, err)
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON format"})
+			return
+		}
+	}
+
+	if err := utils.Validate.Struct(request); err != nil {
+		log.Printf("%s: validation failed: %v", name, err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	event, err := record(&request, plantID, reminderID, userID)
+	switch {
+	case errors.Is(err, service.ErrReminderNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrFutureTimestamp):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("%s: failed to record care event: %v", name, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record care event"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{"event": event})
+}
@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"plant-reminder/constants"
+	"plant-reminder/dto"
+	"plant-reminder/service"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MockCareService struct {
+	CompleteReminderFunc func(*dto.CareEventRequest, int64, int64, int64) (*dto.CareEventResponse, error)
+	SkipReminderFunc     func(*dto.CareEventRequest, int64, int64, int64) (*dto.CareEventResponse, error)
+	GetPlantHistoryFunc  func(int64, int64) (*dto.PlantHistoryResponse, error)
+	GetOverdueTasksFunc  func(int64) ([]dto.OverdueTaskResponse, error)
+}
+
+func (m *MockCareService) CompleteReminder(req *dto.CareEventRequest, plantID, reminderID, userID int64) (*dto.CareEventResponse, error) {
+	if m.CompleteReminderFunc != nil {
+		return m.CompleteReminderFunc(req, plantID, reminderID, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockCareService) SkipReminder(req *dto.CareEventRequest, plantID, reminderID, userID int64) (*dto.CareEventResponse, error) {
+	if m.SkipReminderFunc != nil {
+		return m.SkipReminderFunc(req, plantID, reminderID, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockCareService) GetPlantHistory(plantID, userID int64) (*dto.PlantHistoryResponse, error) {
+	if m.GetPlantHistoryFunc != nil {
+		return m.GetPlantHistoryFunc(plantID, userID)
+	}
+	return nil, nil
+}
+
+func (m *MockCareService) GetOverdueTasks(userID int64) ([]dto.OverdueTaskResponse, error) {
+	if m.GetOverdueTasksFunc != nil {
+		return m.GetOverdueTasksFunc(userID)
+	}
+	return nil, nil
+}
+
+func setupCareController(mockService *MockCareService) (*CareController, *gin.Engine) {
+	router := setupTestRouter()
+	controller := &CareController{careService: mockService}
+	return controller, router
+}
+
+func TestCareController_CompleteReminder_Success(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	mockService.CompleteReminderFunc = func(req *dto.CareEventRequest, plantID, reminderID, userID int64) (*dto.CareEventResponse, error) {
+		if plantID != 1 || reminderID != 2 || userID != 123 {
+			t.Errorf("Unexpected ids: plant %d, reminder %d, user %d", plantID, reminderID, userID)
+		}
+		if req.Note != "bottom watering" {
+			t.Errorf("Expected note 'bottom watering', got %s", req.Note)
+		}
+		return &dto.CareEventResponse{ID: 10, PlantID: plantID, ReminderID: reminderID, Action: constants.CareActionDone, Note: req.Note}, nil
+	}
+
+	router.POST("/plant/:id/reminder/:reminderId/complete", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.CompleteReminder(c)
+	})
+
+	jsonData := []byte(`{"note": "bottom watering"}`)
+	req, _ := http.NewRequest("POST", "/plant/1/reminder/2/complete", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var response map[string]map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["event"]["action"] != "done" {
+		t.Errorf("Expected action 'done', got %v", response["event"]["action"])
+	}
+}
+
+func TestCareController_SkipReminder_EmptyBody(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	called := false
+	mockService.SkipReminderFunc = func(req *dto.CareEventRequest, plantID, reminderID, userID int64) (*dto.CareEventResponse, error) {
+		called = true
+		return &dto.CareEventResponse{ID: 11, Action: constants.CareActionSkipped, Timestamp: time.Now()}, nil
+	}
+
+	router.POST("/plant/:id/reminder/:reminderId/skip", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.SkipReminder(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/plant/1/reminder/2/skip", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	if !called {
+		t.Error("Expected SkipReminder to be called")
+	}
+}
+
+func TestCareController_CompleteReminder_InvalidReminderID(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	router.POST("/plant/:id/reminder/:reminderId/complete", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.CompleteReminder(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/plant/1/reminder/abc/complete", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCareController_CompleteReminder_ServiceError(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	mockService.CompleteReminderFunc = func(req *dto.CareEventRequest, plantID, reminderID, userID int64) (*dto.CareEventResponse, error) {
+		return nil, errors.New("database is down")
+	}
+
+	router.POST("/plant/:id/reminder/:reminderId/complete", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.CompleteReminder(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/plant/1/reminder/99/complete", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestCareController_CompleteReminder_NotFound(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	mockService.CompleteReminderFunc = func(req *dto.CareEventRequest, plantID, reminderID, userID int64) (*dto.CareEventResponse, error) {
+		return nil, service.ErrReminderNotFound
+	}
+
+	router.POST("/plant/:id/reminder/:reminderId/complete", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.CompleteReminder(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/plant/1/reminder/99/complete", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestCareController_CompleteReminder_FutureTimestamp(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	mockService.CompleteReminderFunc = func(req *dto.CareEventRequest, plantID, reminderID, userID int64) (*dto.CareEventResponse, error) {
+		return nil, service.ErrFutureTimestamp
+	}
+
+	router.POST("/plant/:id/reminder/:reminderId/complete", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.CompleteReminder(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/plant/1/reminder/99/complete", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestCareController_GetPlantHistory_Success(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	mockService.GetPlantHistoryFunc = func(plantID, userID int64) (*dto.PlantHistoryResponse, error) {
+		if plantID != 1 {
+			t.Errorf("Expected plantID 1, got %d", plantID)
+		}
+		return &dto.PlantHistoryResponse{
+			PlantID: plantID,
+			Streak:  2,
+			Events: []dto.CareEventResponse{
+				{ID: 3, Action: constants.CareActionDone},
+				{ID: 2, Action: constants.CareActionDone},
+				{ID: 1, Action: constants.CareActionSkipped},
+			},
+		}, nil
+	}
+
+	router.GET("/plant/:id/history", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.GetPlantHistory(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/plant/1/history", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]dto.PlantHistoryResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["history"].Streak != 2 {
+		t.Errorf("Expected streak 2, got %d", response["history"].Streak)
+	}
+	if len(response["history"].Events) != 3 {
+		t.Errorf("Expected 3 events, got %d", len(response["history"].Events))
+	}
+}
+
+func TestCareController_GetPlantHistory_Errors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{service.ErrPlantNotFound, http.StatusNotFound},
+		{errors.New("database is down"), http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		mockService := &MockCareService{}
+		controller, router := setupCareController(mockService)
+
+		mockService.GetPlantHistoryFunc = func(plantID, userID int64) (*dto.PlantHistoryResponse, error) {
+			return nil, test.err
+		}
+
+		router.GET("/plant/:id/history", func(c *gin.Context) {
+			c.Set("userID", int64(123))
+			controller.GetPlantHistory(c)
+		})
+
+		req, _ := http.NewRequest("GET", "/plant/1/history", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("Expected status %d for %v, got %d", test.status, test.err, w.Code)
+		}
+	}
+}
+
+func TestCareController_GetOverdueTasks_Success(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	mockService.GetOverdueTasksFunc = func(userID int64) ([]dto.OverdueTaskResponse, error) {
+		if userID != 123 {
+			t.Errorf("Expected userID 123, got %d", userID)
+		}
+		return []dto.OverdueTaskResponse{
+			{EventID: 5, ReminderID: 2, PlantID: 1, PlantName: "Monstera", TaskType: constants.TaskWater},
+			{EventID: 4, ReminderID: 2, PlantID: 1, PlantName: "Monstera", TaskType: constants.TaskWater},
+		}, nil
+	}
+
+	router.GET("/plant/overdue", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.GetOverdueTasks(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/plant/overdue", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string][]dto.OverdueTaskResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(response["overdue"]) != 2 {
+		t.Errorf("Expected 2 overdue tasks, got %d", len(response["overdue"]))
+	}
+}
+
+func TestCareController_GetOverdueTasks_ServiceError(t *testing.T) {
+	mockService := &MockCareService{}
+	controller, router := setupCareController(mockService)
+
+	mockService.GetOverdueTasksFunc = func(userID int64) ([]dto.OverdueTaskResponse, error) {
+		return nil, errors.New("pq: relation \"care_events\" does not exist")
+	}
+
+	router.GET("/plant/overdue", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.GetOverdueTasks(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/plant/overdue", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["error"] != "failed to get overdue tasks" {
+		t.Errorf("Expected a generic error, got %q", response["error"])
+	}
+}
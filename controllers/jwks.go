@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+	"plant-reminder/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge is how long clients may cache the key set. A new signing key should be
+// in the key directory at least this long before it is used.
+const jwksMaxAge = "public, max-age=300"
+
+type JWKSController struct{}
+
+func NewJWKSController() *JWKSController {
+	return &JWKSController{}
+}
+
+// GetJWKS publishes the public keys our tokens can be verified with.
+func (jc *JWKSController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", jwksMaxAge)
+	ctx.JSON(http.StatusOK, utils.JWKS())
+}
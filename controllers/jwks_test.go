@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"plant-reminder/utils"
+	"testing"
+	"time"
+)
+
+func TestJWKSController_GetJWKS(t *testing.T) {
+	dir := t.TempDir()
+	for kid, keyType := range map[string]string{"a-rsa": utils.KeyTypeRSA, "b-ed": utils.KeyTypeEd25519} {
+		key, err := utils.GenerateTokenKey(keyType)
+		if err != nil {
+			t.Fatalf("GenerateTokenKey failed: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, kid+".pem"), key, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	utils.InitTokens("", time.Hour, 24*time.Hour)
+	if err := utils.LoadTokenKeys(dir, ""); err != nil {
+		t.Fatalf("LoadTokenKeys failed: %v", err)
+	}
+	t.Cleanup(func() { utils.InitTokens("", 0, 0) })
+
+	controller := NewJWKSController()
+	router := setupTestRouter()
+	router.GET("/.well-known/jwks.json", controller.GetJWKS)
+
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var set utils.JSONWebKeySet
+	if err := json.Unmarshal(w.Body.Bytes(), &set); err != nil {
+		t.Fatalf("Failed to decode JWKS: %v", err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %+v", set.Keys)
+	}
+	if rsa := set.Keys[0]; rsa.KeyID != "a-rsa" || rsa.KeyType != "RSA" || rsa.Algorithm != "RS256" || rsa.N == "" || rsa.E != "AQAB" {
+		t.Errorf("Unexpected RSA key: %+v", rsa)
+	}
+	if ed := set.Keys[1]; ed.KeyID != "b-ed" || ed.KeyType != "OKP" || ed.Curve != "Ed25519" || ed.Algorithm != "EdDSA" || ed.X == "" {
+		t.Errorf("Unexpected Ed25519 key: %+v", ed)
+	}
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"plant-reminder/constants"
 	"plant-reminder/dto"
 	"plant-reminder/service"
 	"strconv"
@@ -62,7 +64,11 @@ func (rc *ReminderController) GetPlantReminders(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	reminders, err := rc.reminderService.GetPlantReminders(plantID, userId)
+	taskType, ok := taskFilter(ctx)
+	if !ok {
+		return
+	}
+	reminders, err := rc.reminderService.GetPlantReminders(plantID, userId, taskType)
 	if err != nil {
 		log.Printf("GetReminders: failed to get reminders: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -73,7 +79,11 @@ func (rc *ReminderController) GetPlantReminders(ctx *gin.Context) {
 
 func (rc *ReminderController) GetAllReminders(ctx *gin.Context) {
 	userId := ctx.GetInt64("userID")
-	reminders, err := rc.reminderService.GetUserReminders(userId)
+	taskType, ok := taskFilter(ctx)
+	if !ok {
+		return
+	}
+	reminders, err := rc.reminderService.GetUserReminders(userId, taskType)
 	if err != nil {
 		log.Printf("GetReminders: failed to get reminders: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -82,6 +92,18 @@ func (rc *ReminderController) GetAllReminders(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"reminders": reminders})
 }
 
+// taskFilter reads the optional ?task= query parameter. It writes the error response
+// and returns false when the value is not a known task type.
+func taskFilter(ctx *gin.Context) (constants.TaskType, bool) {
+	taskType := constants.TaskType(ctx.Query("task"))
+	if taskType != "" && !taskType.IsValid() {
+		log.Printf("GetReminders: invalid task filter: %s", taskType)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid task type"})
+		return "", false
+	}
+	return taskType, true
+}
+
 func (rc *ReminderController) DeleteReminder(ctx *gin.Context) {
 	userId := ctx.GetInt64("userID")
 	reminderId, err := strconv.ParseInt(ctx.Param("reminderId"), 10, 64)
@@ -103,6 +125,10 @@ func (rc *ReminderController) DeleteReminder(ctx *gin.Context) {
 func (rc *ReminderController) TestReminder(ctx *gin.Context) {
 	userId := ctx.GetInt64("userID")
 	err := rc.reminderService.TestReminder(userId)
+	if errors.Is(err, service.ErrEmailNotVerified) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "verify your email to receive notifications"})
+		return
+	}
 	if err != nil {
 		log.Printf("DeleteReminder: failed to test reminder: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -148,3 +174,42 @@ func (rc *ReminderController) UpdateReminder(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"reminder": resp})
 }
+
+func (rc *ReminderController) SnoozeReminder(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	plantID, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		log.Printf("SnoozeReminder: invalid plant id: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid plant ID"})
+		return
+	}
+	reminderID, err := strconv.ParseInt(ctx.Param("reminderId"), 10, 64)
+	if err != nil {
+		log.Printf("SnoozeReminder: invalid reminder id: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid reminder ID"})
+		return
+	}
+
+	var snoozeRequest dto.ReminderSnoozeRequest
+	if err := ctx.ShouldBindJSON(&snoozeRequest); err != nil {
+		log.Printf("SnoozeReminder: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid JSON format"})
+		return
+	}
+
+	duration, err := snoozeRequest.ParseDuration()
+	if err != nil {
+		log.Printf("SnoozeReminder: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp, err := rc.reminderService.SnoozeReminder(reminderID, plantID, userID, duration)
+	if err != nil {
+		log.Printf("SnoozeReminder: failed to snooze reminder: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"reminder": resp})
+}
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"plant-reminder/constants"
 	"plant-reminder/dto"
+	"plant-reminder/service"
 	"testing"
 	"time"
 
@@ -16,11 +17,12 @@ import (
 type MockReminderService struct {
 	CreateReminderFunc    func(*dto.ReminderCreateRequest, int64, int64) (*dto.ReminderResponse, error)
 	GetReminderFunc       func(int64, int64) (*dto.ReminderResponse, error)
-	GetPlantRemindersFunc func(int64, int64) ([]dto.ReminderResponse, error)
-	GetUserRemindersFunc  func(int64) ([]dto.ReminderResponse, error)
+	GetPlantRemindersFunc func(int64, int64, constants.TaskType) ([]dto.ReminderResponse, error)
+	GetUserRemindersFunc  func(int64, constants.TaskType) ([]dto.ReminderResponse, error)
 	UpdateReminderFunc    func(*dto.ReminderUpdateRequest, int64, int64) (*dto.ReminderResponse, error)
 	DeleteReminderFunc    func(int64, int64) error
 	TestReminderFunc      func(userId int64) error
+	SnoozeReminderFunc    func(int64, int64, int64, time.Duration) (*dto.ReminderResponse, error)
 }
 
 func (m *MockReminderService) CreateReminder(req *dto.ReminderCreateRequest, plantID int64, userID int64) (*dto.ReminderResponse, error) {
@@ -37,16 +39,16 @@ func (m *MockReminderService) GetReminder(reminderID, userID int64) (*dto.Remind
 	return nil, nil
 }
 
-func (m *MockReminderService) GetPlantReminders(plantID, userID int64) ([]dto.ReminderResponse, error) {
+func (m *MockReminderService) GetPlantReminders(plantID, userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error) {
 	if m.GetPlantRemindersFunc != nil {
-		return m.GetPlantRemindersFunc(plantID, userID)
+		return m.GetPlantRemindersFunc(plantID, userID, taskType)
 	}
 	return nil, nil
 }
 
-func (m *MockReminderService) GetUserReminders(userID int64) ([]dto.ReminderResponse, error) {
+func (m *MockReminderService) GetUserReminders(userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error) {
 	if m.GetUserRemindersFunc != nil {
-		return m.GetUserRemindersFunc(userID)
+		return m.GetUserRemindersFunc(userID, taskType)
 	}
 	return nil, nil
 }
@@ -72,6 +74,13 @@ func (m *MockReminderService) TestReminder(userId int64) error {
 	return nil
 }
 
+func (m *MockReminderService) SnoozeReminder(reminderID, plantID, userID int64, duration time.Duration) (*dto.ReminderResponse, error) {
+	if m.SnoozeReminderFunc != nil {
+		return m.SnoozeReminderFunc(reminderID, plantID, userID, duration)
+	}
+	return nil, nil
+}
+
 func setupReminderController(mockService *MockReminderService) (*ReminderController, *gin.Engine) {
 	router := setupTestRouter()
 	controller := &ReminderController{reminderService: mockService}
@@ -135,7 +144,7 @@ func TestReminderController_GetPlantReminders_Success(t *testing.T) {
 		{ID: 2, TimeOfDay: "18:00", Repeat: constants.RepeatWeekly, NextTriggerTime: time.Now()},
 	}
 
-	mockService.GetPlantRemindersFunc = func(plantID, userID int64) ([]dto.ReminderResponse, error) {
+	mockService.GetPlantRemindersFunc = func(plantID, userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error) {
 		if plantID != 1 {
 			t.Errorf("Expected plantID 1, got %d", plantID)
 		}
@@ -224,3 +233,329 @@ func TestReminderController_TestReminder_Success(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
 }
+
+func TestReminderController_TestReminder_EmailNotVerified(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+	mockService.TestReminderFunc = func(userID int64) error {
+		return service.ErrEmailNotVerified
+	}
+	router.POST("/reminders/test", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.TestReminder(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/reminders/test", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestReminderController_SnoozeReminder_Success(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	mockService.SnoozeReminderFunc = func(reminderID, plantID, userID int64, duration time.Duration) (*dto.ReminderResponse, error) {
+		if reminderID != 2 || plantID != 1 || userID != 123 {
+			t.Errorf("Unexpected ids: reminder %d, plant %d, user %d", reminderID, plantID, userID)
+		}
+		if duration != 30*time.Minute {
+			t.Errorf("Expected duration 30m, got %s", duration)
+		}
+		snoozedUntil := time.Now().Add(duration)
+		return &dto.ReminderResponse{ID: reminderID, SnoozedUntil: &snoozedUntil}, nil
+	}
+
+	router.POST("/plant/:id/reminder/:reminderId/snooze", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.SnoozeReminder(c)
+	})
+
+	jsonData := []byte(`{"duration": "30m"}`)
+	req, _ := http.NewRequest("POST", "/plant/1/reminder/2/snooze", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestReminderController_SnoozeReminder_InvalidDuration(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	router.POST("/plant/:id/reminder/:reminderId/snooze", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.SnoozeReminder(c)
+	})
+
+	for _, body := range []string{`{"duration": "soon"}`, `{"duration": "10s"}`, `{"duration": "48h"}`, `{}`} {
+		req, _ := http.NewRequest("POST", "/plant/1/reminder/2/snooze", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func TestReminderController_AddReminder_EveryNDays(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	mockService.CreateReminderFunc = func(req *dto.ReminderCreateRequest, plantID int64, userID int64) (*dto.ReminderResponse, error) {
+		if req.RepeatType != constants.RepeatEveryNDays {
+			t.Errorf("Expected repeat type everyNDays, got %s", req.RepeatType)
+		}
+		if req.Interval == nil || *req.Interval != 3 {
+			t.Errorf("Expected interval 3, got %v", req.Interval)
+		}
+		return &dto.ReminderResponse{ID: 1, Repeat: req.RepeatType, Interval: req.Interval}, nil
+	}
+
+	router.POST("/plant/:id/reminder", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.AddReminder(c)
+	})
+
+	jsonData := []byte(`{"repeatType": "everyNDays", "interval": 3, "timeOfDay": "08:00"}`)
+	req, _ := http.NewRequest("POST", "/plant/1/reminder", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var response map[string]map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["reminder"]["repeatType"] != "everyNDays" {
+		t.Errorf("Expected repeatType 'everyNDays', got %v", response["reminder"]["repeatType"])
+	}
+}
+
+func TestReminderController_AddReminder_InvalidInterval(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	router.POST("/plant/:id/reminder", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.AddReminder(c)
+	})
+
+	bodies := []string{
+		`{"repeatType": "everyNWeeks", "timeOfDay": "08:00"}`,
+		`{"repeatType": "everyNDays", "interval": 0, "timeOfDay": "08:00"}`,
+		`{"repeatType": "everyNDays", "interval": 3, "dayOfWeek": 1, "timeOfDay": "08:00"}`,
+		`{"repeatType": "daily", "interval": 3, "timeOfDay": "08:00"}`,
+	}
+	for _, body := range bodies {
+		req, _ := http.NewRequest("POST", "/plant/1/reminder", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func TestReminderController_AddReminder_MultipleWeekdays(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	mockService.CreateReminderFunc = func(req *dto.ReminderCreateRequest, plantID int64, userID int64) (*dto.ReminderResponse, error) {
+		if len(req.DaysOfWeek) != 3 {
+			t.Errorf("Expected 3 weekdays, got %v", req.DaysOfWeek)
+		}
+		return &dto.ReminderResponse{ID: 1, Repeat: req.RepeatType, DaysOfWeek: req.DaysOfWeek}, nil
+	}
+
+	router.POST("/plant/:id/reminder", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.AddReminder(c)
+	})
+
+	jsonData := []byte(`{"repeatType": "weekly", "daysOfWeek": [1, 3, 5], "timeOfDay": "07:30"}`)
+	req, _ := http.NewRequest("POST", "/plant/1/reminder", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestReminderController_AddReminder_Cron(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	mockService.CreateReminderFunc = func(req *dto.ReminderCreateRequest, plantID int64, userID int64) (*dto.ReminderResponse, error) {
+		if req.CronExpression != "30 7 * * 1-5" {
+			t.Errorf("Expected cron expression '30 7 * * 1-5', got %s", req.CronExpression)
+		}
+		return &dto.ReminderResponse{ID: 1, Repeat: req.RepeatType, CronExpression: req.CronExpression}, nil
+	}
+
+	router.POST("/plant/:id/reminder", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.AddReminder(c)
+	})
+
+	jsonData := []byte(`{"repeatType": "cron", "cronExpression": "30 7 * * 1-5"}`)
+	req, _ := http.NewRequest("POST", "/plant/1/reminder", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestReminderController_AddReminder_InvalidSchedule(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	router.POST("/plant/:id/reminder", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.AddReminder(c)
+	})
+
+	bodies := []string{
+		`{"repeatType": "weekly", "daysOfWeek": [1, 1], "timeOfDay": "07:30"}`,
+		`{"repeatType": "weekly", "daysOfWeek": [7], "timeOfDay": "07:30"}`,
+		`{"repeatType": "weekly", "dayOfWeek": 2, "daysOfWeek": [1], "timeOfDay": "07:30"}`,
+		`{"repeatType": "daily", "daysOfWeek": [1], "timeOfDay": "07:30"}`,
+		`{"repeatType": "daily"}`,
+		`{"repeatType": "cron", "cronExpression": "every morning"}`,
+		`{"repeatType": "cron", "cronExpression": "0 0 30 2 *"}`,
+		`{"repeatType": "cron", "cronExpression": "TZ=Europe/Kyiv 0 8 * * *"}`,
+		`{"repeatType": "cron", "cronExpression": "0 8 * * *", "timeOfDay": "08:00"}`,
+	}
+	for _, body := range bodies {
+		req, _ := http.NewRequest("POST", "/plant/1/reminder", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func TestReminderController_AddReminder_CustomTask(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	mockService.CreateReminderFunc = func(req *dto.ReminderCreateRequest, plantID int64, userID int64) (*dto.ReminderResponse, error) {
+		if req.TaskType != constants.TaskCustom || req.TaskLabel != "Wipe leaves" {
+			t.Errorf("Expected custom task 'Wipe leaves', got %s %q", req.TaskType, req.TaskLabel)
+		}
+		return &dto.ReminderResponse{ID: 1, TaskType: req.TaskType, TaskLabel: req.TaskLabel}, nil
+	}
+
+	router.POST("/plant/:id/reminder", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.AddReminder(c)
+	})
+
+	jsonData := []byte(`{"taskType": "custom", "taskLabel": "Wipe leaves", "repeatType": "weekly", "dayOfWeek": 6, "timeOfDay": "10:00"}`)
+	req, _ := http.NewRequest("POST", "/plant/1/reminder", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	var response map[string]map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response["reminder"]["taskLabel"] != "Wipe leaves" {
+		t.Errorf("Expected taskLabel 'Wipe leaves', got %v", response["reminder"]["taskLabel"])
+	}
+}
+
+func TestReminderController_AddReminder_InvalidTask(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	router.POST("/plant/:id/reminder", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.AddReminder(c)
+	})
+
+	bodies := []string{
+		`{"taskType": "sing", "repeatType": "daily", "timeOfDay": "08:00"}`,
+		`{"taskType": "custom", "repeatType": "daily", "timeOfDay": "08:00"}`,
+		`{"taskType": "fertilize", "taskLabel": "Feed", "repeatType": "daily", "timeOfDay": "08:00"}`,
+	}
+	for _, body := range bodies {
+		req, _ := http.NewRequest("POST", "/plant/1/reminder", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+}
+
+func TestReminderController_GetAllReminders_TaskFilter(t *testing.T) {
+	mockService := &MockReminderService{}
+	controller, router := setupReminderController(mockService)
+
+	mockService.GetUserRemindersFunc = func(userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error) {
+		if taskType != constants.TaskFertilize {
+			t.Errorf("Expected task filter 'fertilize', got %q", taskType)
+		}
+		return []dto.ReminderResponse{{ID: 1, TaskType: taskType}}, nil
+	}
+
+	router.GET("/reminders", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.GetAllReminders(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/reminders?task=fertilize", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	req, _ = http.NewRequest("GET", "/reminders?task=dance", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for unknown task, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -1,15 +1,16 @@
 package controllers
 
 import (
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"plant-reminder/dto"
 	"plant-reminder/service"
 	"plant-reminder/utils"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 type UserController struct {
@@ -34,10 +35,82 @@ func (uc *UserController) Login(ctx *gin.Context) {
 		return
 	}
 
-	authResponse, err := uc.userService.VerifyUser(loginRequest.Email, loginRequest.Password)
+	authResponse, challenge, err := uc.userService.VerifyUser(loginRequest.Email, loginRequest.Password)
+	if respondLocked(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if challenge != nil {
+		ctx.JSON(http.StatusOK, challenge)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authResponse)
+}
+
+func (uc *UserController) LoginTwoFactor(ctx *gin.Context) {
+	var req dto.TwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authResponse, err := uc.userService.VerifyTwoFactor(&req)
+	if respondLocked(ctx, err) {
+		return
+	}
+	if errors.Is(err, service.ErrInvalidChallenge) || errors.Is(err, service.ErrInvalidTwoFactorCode) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
+		log.Printf("LoginTwoFactor: failed to verify second factor: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authResponse)
+}
+
+func (uc *UserController) LoginWithProvider(ctx *gin.Context) {
+	var req dto.OIDCLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authResponse, challenge, err := uc.userService.LoginWithProvider(ctx.Param("provider"), &req)
+	switch {
+	case errors.Is(err, service.ErrUnknownProvider):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrProviderLoginFailed):
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
+	case errors.Is(err, service.ErrProviderEmailNotVerified):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("LoginWithProvider: failed to log in with %s: %v", ctx.Param("provider"), err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log in"})
+		return
+	}
+	if challenge != nil {
+		ctx.JSON(http.StatusOK, challenge)
+		return
 	}
 
 	ctx.JSON(http.StatusOK, authResponse)
@@ -65,49 +138,265 @@ func (uc *UserController) SignUp(ctx *gin.Context) {
 }
 
 func (uc *UserController) RefreshToken(ctx *gin.Context) {
-	var req struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
+	var req dto.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is required"})
+		return
 	}
 
+	tokens, err := uc.userService.RefreshTokens(req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("RefreshToken: failed to refresh tokens: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh tokens"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (uc *UserController) Logout(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	var req dto.RefreshTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "refresh token is required"})
 		return
 	}
 
-	token, err := utils.VerifyRefreshToken(req.RefreshToken)
+	err := uc.userService.Logout(userID, req.RefreshToken)
+	if errors.Is(err, service.ErrInvalidRefreshToken) {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Logout: failed to revoke session: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+func (uc *UserController) LogoutAll(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+
+	if err := uc.userService.LogoutAll(userID); err != nil {
+		log.Printf("LogoutAll: failed to revoke tokens: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "logged out of all sessions successfully"})
+}
+
+func (uc *UserController) ChangePassword(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	var req dto.ChangePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("ChangePassword: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		log.Printf("ChangePassword: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := uc.userService.ChangePassword(userID, &req)
+	if errors.Is(err, service.ErrWrongPassword) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "current password is wrong"})
+		return
+	}
+	if err != nil {
+		log.Printf("ChangePassword: failed to change password: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to change password"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (uc *UserController) ForgotPassword(ctx *gin.Context) {
+	var req dto.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("ForgotPassword: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		log.Printf("ForgotPassword: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := uc.userService.RequestPasswordReset(&req); err != nil {
+		log.Printf("ForgotPassword: failed to request password reset: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to request password reset"})
+		return
+	}
+
+	// The same response for every email, so it doesn't reveal who has an account.
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "if an account with this email exists, a reset link has been sent"})
+}
+
+func (uc *UserController) ResetPassword(ctx *gin.Context) {
+	var req dto.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("ResetPassword: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		log.Printf("ResetPassword: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := uc.userService.ResetPassword(&req)
+	if errors.Is(err, service.ErrInvalidResetToken) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		log.Printf("ResetPassword: failed to reset password: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
 		return
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
+	ctx.JSON(http.StatusOK, gin.H{"message": "password reset successfully"})
+}
+
+func (uc *UserController) VerifyEmail(ctx *gin.Context) {
+	var req dto.VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("VerifyEmail: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
-	userID, ok := claims["userID"].(float64)
-	if !ok {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid userID in token"})
+	if err := utils.Validate.Struct(req); err != nil {
+		log.Printf("VerifyEmail: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := uc.userService.VerifyEmail(&req)
+	if errors.Is(err, service.ErrInvalidVerificationToken) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("VerifyEmail: failed to verify email: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify email"})
 		return
 	}
 
-	newAccessToken, err := utils.SignPayload(int64(userID))
+	ctx.JSON(http.StatusOK, gin.H{"message": "email verified successfully"})
+}
+
+func (uc *UserController) ResendVerification(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+
+	err := uc.userService.ResendVerification(userID)
+	if errors.Is(err, service.ErrEmailAlreadyVerified) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate new access token"})
+		log.Printf("ResendVerification: failed to send verification email: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to send verification email"})
 		return
 	}
 
-	newRefreshToken, err := utils.SignRefreshToken(int64(userID))
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "verification email sent"})
+}
+
+func (uc *UserController) EnrollTwoFactor(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+
+	enrollment, err := uc.userService.EnrollTwoFactor(userID)
+	if errors.Is(err, service.ErrTwoFactorEnabled) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate new refresh token"})
+		log.Printf("EnrollTwoFactor: failed to enroll: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set up two-factor authentication"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+func (uc *UserController) ConfirmTwoFactor(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	var req dto.TwoFactorConfirmRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("ConfirmTwoFactor: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		log.Printf("ConfirmTwoFactor: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	recoveryCodes, err := uc.userService.ConfirmTwoFactor(userID, &req)
+	switch {
+	case errors.Is(err, service.ErrTwoFactorEnabled), errors.Is(err, service.ErrTwoFactorNotEnrolled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, service.ErrInvalidTwoFactorCode):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("ConfirmTwoFactor: failed to enable two-factor authentication: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to enable two-factor authentication"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, recoveryCodes)
+}
+
+func (uc *UserController) DisableTwoFactor(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	var req dto.TwoFactorDisableRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("DisableTwoFactor: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"access_token":  newAccessToken,
-		"refresh_token": newRefreshToken,
-	})
+	if err := utils.Validate.Struct(req); err != nil {
+		log.Printf("DisableTwoFactor: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := uc.userService.DisableTwoFactor(userID, &req)
+	switch {
+	case errors.Is(err, service.ErrWrongPassword):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "password is wrong"})
+		return
+	case errors.Is(err, service.ErrTwoFactorNotEnabled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("DisableTwoFactor: failed to disable two-factor authentication: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to disable two-factor authentication"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
 }
 
 func (uc *UserController) SetPushToken(ctx *gin.Context) {
@@ -126,7 +415,7 @@ func (uc *UserController) SetPushToken(ctx *gin.Context) {
 		return
 	}
 
-	err := uc.userService.SetPushToken(fmt.Sprintf("%d", userID), req.Token)
+	err := uc.userService.SetPushToken(userID, &req)
 	if err != nil {
 		log.Printf("SetPushToken: failed to set push token: %v", err)
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -136,6 +425,41 @@ func (uc *UserController) SetPushToken(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "push token set successfully"})
 }
 
+func (uc *UserController) GetDevices(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	devices, err := uc.userService.GetDevices(userID)
+	if err != nil {
+		log.Printf("GetDevices: failed to get devices: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+func (uc *UserController) DeleteDevice(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	deviceID, err := strconv.ParseInt(ctx.Param("deviceId"), 10, 64)
+	if err != nil {
+		log.Printf("DeleteDevice: invalid device id: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = uc.userService.DeleteDevice(userID, deviceID)
+	if errors.Is(err, service.ErrDeviceNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("DeleteDevice: failed to delete device: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
 func (uc *UserController) DeleteUser(ctx *gin.Context) {
 	userID := ctx.GetInt64("userID")
 	err := uc.userService.DeleteUser(userID)
@@ -163,3 +487,103 @@ func (uc *UserController) GetMyProfile(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, gin.H{"user": userResponse})
 }
+
+func (uc *UserController) UpdateMyProfile(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	var req dto.UserUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("UpdateMyProfile: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := utils.Validate.Struct(req); err != nil {
+		log.Printf("UpdateMyProfile: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userResponse, err := uc.userService.UpdateProfile(userID, &req)
+	if err != nil {
+		log.Printf("UpdateMyProfile: failed to update user: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"user": userResponse})
+}
+
+func (uc *UserController) CreateAPIKey(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	var req dto.APIKeyCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Printf("CreateAPIKey: failed to bind JSON: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		log.Printf("CreateAPIKey: validation failed: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	apiKey, err := uc.userService.CreateAPIKey(userID, &req)
+	if errors.Is(err, service.ErrTooManyAPIKeys) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("CreateAPIKey: failed to create API key: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create API key"})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, apiKey)
+}
+
+func (uc *UserController) GetAPIKeys(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	apiKeys, err := uc.userService.GetAPIKeys(userID)
+	if err != nil {
+		log.Printf("GetAPIKeys: failed to get API keys: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"apiKeys": apiKeys})
+}
+
+func (uc *UserController) DeleteAPIKey(ctx *gin.Context) {
+	userID := ctx.GetInt64("userID")
+	keyID, err := strconv.ParseInt(ctx.Param("keyId"), 10, 64)
+	if err != nil {
+		log.Printf("DeleteAPIKey: invalid key id: %v", err)
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = uc.userService.DeleteAPIKey(userID, keyID)
+	if errors.Is(err, service.ErrAPIKeyNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("DeleteAPIKey: failed to delete API key: %v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// respondLocked answers logins to a locked account with 429 and when to try again.
+func respondLocked(ctx *gin.Context, err error) bool {
+	var locked *service.AccountLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	ctx.Header("Retry-After", utils.RetryAfter(time.Until(locked.Until)))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{"error": locked.Error()})
+	return true
+}
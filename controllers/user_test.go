@@ -7,18 +7,40 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"plant-reminder/dto"
+	"plant-reminder/service"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 // MockUserService is a mock implementation of UserService for testing
 type MockUserService struct {
-	CreateUserFunc   func(*dto.UserCreateRequest) (*dto.AuthResponse, error)
-	VerifyUserFunc   func(string, string) (*dto.AuthResponse, error)
-	SetPushTokenFunc func(string, string) error
-	DeleteUserFunc   func(int64) error
-	GetUserFunc      func(int64) (*dto.UserResponse, error)
+	CreateUserFunc    func(*dto.UserCreateRequest) (*dto.AuthResponse, error)
+	VerifyUserFunc    func(string, string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error)
+	SetPushTokenFunc  func(int64, *dto.PushTokenRequest) error
+	GetDevicesFunc    func(int64) ([]dto.DeviceResponse, error)
+	DeleteDeviceFunc  func(int64, int64) error
+	DeleteUserFunc    func(int64) error
+	GetUserFunc       func(int64) (*dto.UserResponse, error)
+	UpdateProfileFunc func(int64, *dto.UserUpdateRequest) (*dto.UserResponse, error)
+	RefreshTokensFunc func(string) (*dto.TokenResponse, error)
+	LogoutFunc        func(int64, string) error
+	LogoutAllFunc     func(int64) error
+
+	ChangePasswordFunc       func(int64, *dto.ChangePasswordRequest) (*dto.TokenResponse, error)
+	RequestPasswordResetFunc func(*dto.ForgotPasswordRequest) error
+	ResetPasswordFunc        func(*dto.ResetPasswordRequest) error
+	VerifyEmailFunc          func(*dto.VerifyEmailRequest) error
+	ResendVerificationFunc   func(int64) error
+	VerifyTwoFactorFunc      func(*dto.TwoFactorLoginRequest) (*dto.AuthResponse, error)
+	EnrollTwoFactorFunc      func(int64) (*dto.TwoFactorEnrollResponse, error)
+	ConfirmTwoFactorFunc     func(int64, *dto.TwoFactorConfirmRequest) (*dto.RecoveryCodesResponse, error)
+	DisableTwoFactorFunc     func(int64, *dto.TwoFactorDisableRequest) error
+	CreateAPIKeyFunc         func(int64, *dto.APIKeyCreateRequest) (*dto.APIKeyCreatedResponse, error)
+	GetAPIKeysFunc           func(int64) ([]dto.APIKeyResponse, error)
+	DeleteAPIKeyFunc         func(int64, int64) error
+	LoginWithProviderFunc    func(string, *dto.OIDCLoginRequest) (*dto.AuthResponse, *dto.TwoFactorChallenge, error)
 }
 
 func (m *MockUserService) CreateUser(req *dto.UserCreateRequest) (*dto.AuthResponse, error) {
@@ -28,16 +50,30 @@ func (m *MockUserService) CreateUser(req *dto.UserCreateRequest) (*dto.AuthRespo
 	return nil, nil
 }
 
-func (m *MockUserService) VerifyUser(email, password string) (*dto.AuthResponse, error) {
+func (m *MockUserService) VerifyUser(email, password string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
 	if m.VerifyUserFunc != nil {
 		return m.VerifyUserFunc(email, password)
 	}
-	return nil, nil
+	return nil, nil, nil
 }
 
-func (m *MockUserService) SetPushToken(userID, token string) error {
+func (m *MockUserService) SetPushToken(userID int64, req *dto.PushTokenRequest) error {
 	if m.SetPushTokenFunc != nil {
-		return m.SetPushTokenFunc(userID, token)
+		return m.SetPushTokenFunc(userID, req)
+	}
+	return nil
+}
+
+func (m *MockUserService) GetDevices(userID int64) ([]dto.DeviceResponse, error) {
+	if m.GetDevicesFunc != nil {
+		return m.GetDevicesFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) DeleteDevice(userID, deviceID int64) error {
+	if m.DeleteDeviceFunc != nil {
+		return m.DeleteDeviceFunc(userID, deviceID)
 	}
 	return nil
 }
@@ -56,6 +92,125 @@ func (m *MockUserService) GetUser(userID int64) (*dto.UserResponse, error) {
 	return nil, nil
 }
 
+func (m *MockUserService) UpdateProfile(userID int64, req *dto.UserUpdateRequest) (*dto.UserResponse, error) {
+	if m.UpdateProfileFunc != nil {
+		return m.UpdateProfileFunc(userID, req)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) RefreshTokens(refreshToken string) (*dto.TokenResponse, error) {
+	if m.RefreshTokensFunc != nil {
+		return m.RefreshTokensFunc(refreshToken)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) Logout(userID int64, refreshToken string) error {
+	if m.LogoutFunc != nil {
+		return m.LogoutFunc(userID, refreshToken)
+	}
+	return nil
+}
+
+func (m *MockUserService) LogoutAll(userID int64) error {
+	if m.LogoutAllFunc != nil {
+		return m.LogoutAllFunc(userID)
+	}
+	return nil
+}
+
+func (m *MockUserService) ChangePassword(userID int64, req *dto.ChangePasswordRequest) (*dto.TokenResponse, error) {
+	if m.ChangePasswordFunc != nil {
+		return m.ChangePasswordFunc(userID, req)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) RequestPasswordReset(req *dto.ForgotPasswordRequest) error {
+	if m.RequestPasswordResetFunc != nil {
+		return m.RequestPasswordResetFunc(req)
+	}
+	return nil
+}
+
+func (m *MockUserService) ResetPassword(req *dto.ResetPasswordRequest) error {
+	if m.ResetPasswordFunc != nil {
+		return m.ResetPasswordFunc(req)
+	}
+	return nil
+}
+
+func (m *MockUserService) VerifyEmail(req *dto.VerifyEmailRequest) error {
+	if m.VerifyEmailFunc != nil {
+		return m.VerifyEmailFunc(req)
+	}
+	return nil
+}
+
+func (m *MockUserService) ResendVerification(userID int64) error {
+	if m.ResendVerificationFunc != nil {
+		return m.ResendVerificationFunc(userID)
+	}
+	return nil
+}
+
+func (m *MockUserService) VerifyTwoFactor(req *dto.TwoFactorLoginRequest) (*dto.AuthResponse, error) {
+	if m.VerifyTwoFactorFunc != nil {
+		return m.VerifyTwoFactorFunc(req)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) EnrollTwoFactor(userID int64) (*dto.TwoFactorEnrollResponse, error) {
+	if m.EnrollTwoFactorFunc != nil {
+		return m.EnrollTwoFactorFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) ConfirmTwoFactor(userID int64, req *dto.TwoFactorConfirmRequest) (*dto.RecoveryCodesResponse, error) {
+	if m.ConfirmTwoFactorFunc != nil {
+		return m.ConfirmTwoFactorFunc(userID, req)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) DisableTwoFactor(userID int64, req *dto.TwoFactorDisableRequest) error {
+	if m.DisableTwoFactorFunc != nil {
+		return m.DisableTwoFactorFunc(userID, req)
+	}
+	return nil
+}
+
+func (m *MockUserService) CreateAPIKey(userID int64, req *dto.APIKeyCreateRequest) (*dto.APIKeyCreatedResponse, error) {
+	if m.CreateAPIKeyFunc != nil {
+		return m.CreateAPIKeyFunc(userID, req)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) GetAPIKeys(userID int64) ([]dto.APIKeyResponse, error) {
+	if m.GetAPIKeysFunc != nil {
+		return m.GetAPIKeysFunc(userID)
+	}
+	return nil, nil
+}
+
+func (m *MockUserService) DeleteAPIKey(userID, keyID int64) error {
+	if m.DeleteAPIKeyFunc != nil {
+		return m.DeleteAPIKeyFunc(userID, keyID)
+	}
+	return nil
+}
+
+func (m *MockUserService) LoginWithProvider(provider string, req *dto.OIDCLoginRequest) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+	if m.LoginWithProviderFunc != nil {
+		return m.LoginWithProviderFunc(provider, req)
+	}
+	return nil, nil, nil
+}
+
 func setupUserController(mockService *MockUserService) (*UserController, *gin.Engine) {
 	router := setupTestRouter()
 	controller := &UserController{userService: mockService}
@@ -200,14 +355,14 @@ func TestUserController_Login_Success(t *testing.T) {
 		RefreshToken: "refresh_token",
 	}
 
-	mockService.VerifyUserFunc = func(email, password string) (*dto.AuthResponse, error) {
+	mockService.VerifyUserFunc = func(email, password string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
 		if email != "test@example.com" {
 			t.Errorf("Expected email 'test@example.com', got %s", email)
 		}
 		if password != "password123" {
 			t.Errorf("Expected password 'password123', got %s", password)
 		}
-		return expectedResponse, nil
+		return expectedResponse, nil, nil
 	}
 
 	router.POST("/login", controller.Login)
@@ -243,8 +398,8 @@ func TestUserController_Login_InvalidCredentials(t *testing.T) {
 	mockService := &MockUserService{}
 	controller, router := setupUserController(mockService)
 
-	mockService.VerifyUserFunc = func(email, password string) (*dto.AuthResponse, error) {
-		return nil, errors.New("invalid credentials")
+	mockService.VerifyUserFunc = func(email, password string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+		return nil, nil, errors.New("invalid credentials")
 	}
 
 	router.POST("/login", controller.Login)
@@ -266,31 +421,189 @@ func TestUserController_Login_InvalidCredentials(t *testing.T) {
 	}
 }
 
-func TestUserController_SetPushToken_Success(t *testing.T) {
+func TestUserController_Login_Locked(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.VerifyUserFunc = func(email, password string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+		return nil, nil, &service.AccountLockedError{Until: time.Now().Add(90 * time.Second)}
+	}
+
+	router.POST("/login", controller.Login)
+
+	jsonData, _ := json.Marshal(dto.UserLoginRequest{Email: "test@example.com", Password: "password123"})
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if retryAfter := w.Header().Get("Retry-After"); retryAfter != "90" {
+		t.Errorf("Expected Retry-After 90, got %q", retryAfter)
+	}
+}
+
+func TestUserController_Login_TwoFactorChallenge(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.VerifyUserFunc = func(email, password string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+		return nil, &dto.TwoFactorChallenge{TwoFactorRequired: true, ChallengeToken: "challenge"}, nil
+	}
+
+	router.POST("/login", controller.Login)
+
+	jsonData, _ := json.Marshal(dto.UserLoginRequest{Email: "test@example.com", Password: "password123"})
+	req, _ := http.NewRequest("POST", "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response dto.TwoFactorChallenge
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if !response.TwoFactorRequired || response.ChallengeToken != "challenge" {
+		t.Errorf("Expected a two-factor challenge, got %s", w.Body.String())
+	}
+}
+
+func TestUserController_LoginWithProvider_Success(t *testing.T) {
 	mockService := &MockUserService{}
 	controller, router := setupUserController(mockService)
 
-	mockService.SetPushTokenFunc = func(userID, token string) error {
-		if userID != "123" {
-			t.Errorf("Expected userID '123', got %s", userID)
+	mockService.LoginWithProviderFunc = func(provider string, req *dto.OIDCLoginRequest) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+		if provider != "google" {
+			t.Errorf("Expected provider 'google', got %s", provider)
 		}
-		if token != "push_token_123" {
-			t.Errorf("Expected token 'push_token_123', got %s", token)
+		if req.Code != "code" || req.RedirectURI != "app:/callback" {
+			t.Errorf("Expected the code and redirect URI, got %+v", req)
 		}
-		return nil
+		return &dto.AuthResponse{User: dto.UserResponse{ID: 1, Email: "test@example.com"}, AccessToken: "access_token"}, nil, nil
 	}
 
-	router.POST("/push-token", func(c *gin.Context) {
-		c.Set("userID", int64(123))
-		controller.SetPushToken(c)
-	})
+	router.POST("/login/oidc/:provider", controller.LoginWithProvider)
 
-	requestBody := dto.PushTokenRequest{
-		Token: "push_token_123",
+	jsonData, _ := json.Marshal(dto.OIDCLoginRequest{Code: "code", RedirectURI: "app:/callback", Nonce: "n-1"})
+	req, _ := http.NewRequest("POST", "/login/oidc/google", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
-	jsonData, _ := json.Marshal(requestBody)
 
-	req, _ := http.NewRequest("POST", "/push-token", bytes.NewBuffer(jsonData))
+	var response dto.AuthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.AccessToken != "access_token" {
+		t.Errorf("Expected the auth response, got %s", w.Body.String())
+	}
+}
+
+func TestUserController_LoginWithProvider_Errors(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{service.ErrUnknownProvider, http.StatusNotFound},
+		{service.ErrProviderLoginFailed, http.StatusUnauthorized},
+		{service.ErrProviderEmailNotVerified, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		mockService := &MockUserService{}
+		controller, router := setupUserController(mockService)
+
+		mockService.LoginWithProviderFunc = func(provider string, req *dto.OIDCLoginRequest) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+			return nil, nil, test.err
+		}
+
+		router.POST("/login/oidc/:provider", controller.LoginWithProvider)
+
+		jsonData, _ := json.Marshal(dto.OIDCLoginRequest{Code: "code", RedirectURI: "app:/callback", Nonce: "n-1"})
+		req, _ := http.NewRequest("POST", "/login/oidc/google", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != test.status {
+			t.Errorf("Expected status %d for %v, got %d", test.status, test.err, w.Code)
+		}
+	}
+}
+
+func TestUserController_LoginWithProvider_RequiresNonce(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.LoginWithProviderFunc = func(provider string, req *dto.OIDCLoginRequest) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+		t.Error("Expected the request to be rejected before logging in")
+		return nil, nil, nil
+	}
+
+	router.POST("/login/oidc/:provider", controller.LoginWithProvider)
+
+	jsonData, _ := json.Marshal(dto.OIDCLoginRequest{Code: "code", RedirectURI: "app:/callback"})
+	req, _ := http.NewRequest("POST", "/login/oidc/google", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_LoginTwoFactor_InvalidCode(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.VerifyTwoFactorFunc = func(req *dto.TwoFactorLoginRequest) (*dto.AuthResponse, error) {
+		if req.ChallengeToken != "challenge" || req.Code != "123456" {
+			t.Errorf("Unexpected request: %+v", req)
+		}
+		return nil, service.ErrInvalidTwoFactorCode
+	}
+
+	router.POST("/login/2fa", controller.LoginTwoFactor)
+
+	jsonData, _ := json.Marshal(dto.TwoFactorLoginRequest{ChallengeToken: "challenge", Code: "123456"})
+	req, _ := http.NewRequest("POST", "/login/2fa", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestUserController_ConfirmTwoFactor_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.ConfirmTwoFactorFunc = func(userID int64, req *dto.TwoFactorConfirmRequest) (*dto.RecoveryCodesResponse, error) {
+		return &dto.RecoveryCodesResponse{RecoveryCodes: []string{"AAAA-BBBB-CCCC-DDDD"}}, nil
+	}
+
+	router.POST("/user/2fa/confirm", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.ConfirmTwoFactor(c)
+	})
+
+	jsonData, _ := json.Marshal(dto.TwoFactorConfirmRequest{Code: "123456"})
+	req, _ := http.NewRequest("POST", "/user/2fa/confirm", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
@@ -299,25 +612,70 @@ func TestUserController_SetPushToken_Success(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
+
+	var response dto.RecoveryCodesResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if len(response.RecoveryCodes) != 1 {
+		t.Errorf("Expected the recovery codes, got %s", w.Body.String())
+	}
 }
 
-func TestUserController_DeleteUser_Success(t *testing.T) {
+func TestUserController_DisableTwoFactor_WrongPassword(t *testing.T) {
 	mockService := &MockUserService{}
 	controller, router := setupUserController(mockService)
 
-	mockService.DeleteUserFunc = func(userID int64) error {
+	mockService.DisableTwoFactorFunc = func(userID int64, req *dto.TwoFactorDisableRequest) error {
+		return service.ErrWrongPassword
+	}
+
+	router.POST("/user/2fa/disable", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.DisableTwoFactor(c)
+	})
+
+	jsonData, _ := json.Marshal(dto.TwoFactorDisableRequest{Password: "wrong"})
+	req, _ := http.NewRequest("POST", "/user/2fa/disable", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestUserController_SetPushToken_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.SetPushTokenFunc = func(userID int64, req *dto.PushTokenRequest) error {
 		if userID != 123 {
 			t.Errorf("Expected userID 123, got %d", userID)
 		}
+		if req.Token != "push_token_123" {
+			t.Errorf("Expected token 'push_token_123', got %s", req.Token)
+		}
+		if req.Platform != "android" {
+			t.Errorf("Expected platform 'android', got %s", req.Platform)
+		}
 		return nil
 	}
 
-	router.DELETE("/user", func(c *gin.Context) {
+	router.POST("/push-token", func(c *gin.Context) {
 		c.Set("userID", int64(123))
-		controller.DeleteUser(c)
+		controller.SetPushToken(c)
 	})
 
-	req, _ := http.NewRequest("DELETE", "/user", nil)
+	requestBody := dto.PushTokenRequest{
+		Token:    "push_token_123",
+		Platform: "android",
+	}
+	jsonData, _ := json.Marshal(requestBody)
+
+	req, _ := http.NewRequest("POST", "/push-token", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -326,29 +684,47 @@ func TestUserController_DeleteUser_Success(t *testing.T) {
 	}
 }
 
-func TestUserController_GetMyProfile_Success(t *testing.T) {
+func TestUserController_SetPushToken_InvalidPlatform(t *testing.T) {
 	mockService := &MockUserService{}
 	controller, router := setupUserController(mockService)
 
-	expectedUser := &dto.UserResponse{
-		ID:    123,
-		Email: "test@example.com",
-		Name:  "testuser",
+	router.POST("/push-token", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.SetPushToken(c)
+	})
+
+	jsonData := []byte(`{"token": "push_token_123", "platform": "toaster"}`)
+	req, _ := http.NewRequest("POST", "/push-token", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
 	}
+}
 
-	mockService.GetUserFunc = func(userID int64) (*dto.UserResponse, error) {
+func TestUserController_GetDevices_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.GetDevicesFunc = func(userID int64) ([]dto.DeviceResponse, error) {
 		if userID != 123 {
 			t.Errorf("Expected userID 123, got %d", userID)
 		}
-		return expectedUser, nil
+		return []dto.DeviceResponse{
+			{ID: 1, Platform: "ios"},
+			{ID: 2, Platform: "android"},
+		}, nil
 	}
 
-	router.GET("/profile", func(c *gin.Context) {
+	router.GET("/user/devices", func(c *gin.Context) {
 		c.Set("userID", int64(123))
-		controller.GetMyProfile(c)
+		controller.GetDevices(c)
 	})
 
-	req, _ := http.NewRequest("GET", "/profile", nil)
+	req, _ := http.NewRequest("GET", "/user/devices", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
@@ -357,18 +733,155 @@ func TestUserController_GetMyProfile_Success(t *testing.T) {
 	}
 
 	var response map[string]interface{}
-	err := json.Unmarshal(w.Body.Bytes(), &response)
-	if err != nil {
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("Failed to unmarshal response: %v", err)
 	}
 
-	user, ok := response["user"].(map[string]interface{})
+	devices, ok := response["devices"].([]interface{})
 	if !ok {
-		t.Fatal("Expected user in response")
+		t.Fatal("Expected devices array in response")
 	}
-
-	if user["email"] != "test@example.com" {
-		t.Errorf("Expected email 'test@example.com', got %s", user["email"])
+	if len(devices) != 2 {
+		t.Errorf("Expected 2 devices, got %d", len(devices))
+	}
+}
+
+func TestUserController_DeleteDevice_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.DeleteDeviceFunc = func(userID, deviceID int64) error {
+		if userID != 123 {
+			t.Errorf("Expected userID 123, got %d", userID)
+		}
+		if deviceID != 7 {
+			t.Errorf("Expected deviceID 7, got %d", deviceID)
+		}
+		return nil
+	}
+
+	router.DELETE("/user/devices/:deviceId", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.DeleteDevice(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", "/user/devices/7", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+}
+
+func TestUserController_DeleteDevice_NotFound(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.DeleteDeviceFunc = func(userID, deviceID int64) error {
+		return service.ErrDeviceNotFound
+	}
+
+	router.DELETE("/user/devices/:deviceId", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.DeleteDevice(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", "/user/devices/7", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestUserController_DeleteDevice_InvalidID(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	router.DELETE("/user/devices/:deviceId", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.DeleteDevice(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", "/user/devices/abc", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_DeleteUser_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.DeleteUserFunc = func(userID int64) error {
+		if userID != 123 {
+			t.Errorf("Expected userID 123, got %d", userID)
+		}
+		return nil
+	}
+
+	router.DELETE("/user", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.DeleteUser(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", "/user", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestUserController_GetMyProfile_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	expectedUser := &dto.UserResponse{
+		ID:    123,
+		Email: "test@example.com",
+		Name:  "testuser",
+	}
+
+	mockService.GetUserFunc = func(userID int64) (*dto.UserResponse, error) {
+		if userID != 123 {
+			t.Errorf("Expected userID 123, got %d", userID)
+		}
+		return expectedUser, nil
+	}
+
+	router.GET("/profile", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.GetMyProfile(c)
+	})
+
+	req, _ := http.NewRequest("GET", "/profile", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	user, ok := response["user"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected user in response")
+	}
+
+	if user["email"] != "test@example.com" {
+		t.Errorf("Expected email 'test@example.com', got %s", user["email"])
 	}
 }
 
@@ -393,3 +906,495 @@ func TestUserController_GetMyProfile_UserNotFound(t *testing.T) {
 		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
 	}
 }
+
+func TestUserController_UpdateMyProfile_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.UpdateProfileFunc = func(userID int64, req *dto.UserUpdateRequest) (*dto.UserResponse, error) {
+		if userID != 123 {
+			t.Errorf("Expected userID 123, got %d", userID)
+		}
+		if req.TimeZone != "Europe/Kyiv" {
+			t.Errorf("Expected time zone 'Europe/Kyiv', got %s", req.TimeZone)
+		}
+		return &dto.UserResponse{ID: 123, TimeZone: req.TimeZone}, nil
+	}
+
+	router.PUT("/user/me", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.UpdateMyProfile(c)
+	})
+
+	jsonData := []byte(`{"timeZone": "Europe/Kyiv"}`)
+	req, _ := http.NewRequest("PUT", "/user/me", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+
+	user, ok := response["user"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected user in response")
+	}
+	if user["timeZone"] != "Europe/Kyiv" {
+		t.Errorf("Expected time zone 'Europe/Kyiv', got %v", user["timeZone"])
+	}
+}
+
+func TestUserController_UpdateMyProfile_InvalidTimeZone(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	router.PUT("/user/me", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.UpdateMyProfile(c)
+	})
+
+	jsonData := []byte(`{"timeZone": "Mars/Olympus_Mons"}`)
+	req, _ := http.NewRequest("PUT", "/user/me", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_SignUp_InvalidTimeZone(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	router.POST("/signup", controller.SignUp)
+
+	jsonData := []byte(`{"email": "test@example.com", "password": "password123", "timeZone": "Nowhere/City"}`)
+	req, _ := http.NewRequest("POST", "/signup", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_RefreshToken_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.RefreshTokensFunc = func(refreshToken string) (*dto.TokenResponse, error) {
+		if refreshToken != "old_refresh_token" {
+			t.Errorf("Expected refresh token 'old_refresh_token', got %s", refreshToken)
+		}
+		return &dto.TokenResponse{AccessToken: "access_token", RefreshToken: "refresh_token"}, nil
+	}
+
+	router.POST("/refresh", controller.RefreshToken)
+
+	jsonData, _ := json.Marshal(dto.RefreshTokenRequest{RefreshToken: "old_refresh_token"})
+	req, _ := http.NewRequest("POST", "/refresh", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response dto.TokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if response.RefreshToken != "refresh_token" {
+		t.Errorf("Expected the rotated refresh token, got %s", response.RefreshToken)
+	}
+}
+
+func TestUserController_RefreshToken_Reused(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.RefreshTokensFunc = func(refreshToken string) (*dto.TokenResponse, error) {
+		return nil, service.ErrInvalidRefreshToken
+	}
+
+	router.POST("/refresh", controller.RefreshToken)
+
+	jsonData, _ := json.Marshal(dto.RefreshTokenRequest{RefreshToken: "used_refresh_token"})
+	req, _ := http.NewRequest("POST", "/refresh", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestUserController_Logout_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.LogoutFunc = func(userID int64, refreshToken string) error {
+		if userID != 123 || refreshToken != "refresh_token" {
+			t.Errorf("Unexpected logout of user %d with %s", userID, refreshToken)
+		}
+		return nil
+	}
+
+	router.POST("/logout", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.Logout(c)
+	})
+
+	jsonData, _ := json.Marshal(dto.RefreshTokenRequest{RefreshToken: "refresh_token"})
+	req, _ := http.NewRequest("POST", "/logout", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestUserController_Logout_MissingToken(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	router.POST("/logout", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.Logout(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/logout", bytes.NewBufferString("{}"))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_LogoutAll_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	called := false
+	mockService.LogoutAllFunc = func(userID int64) error {
+		called = userID == 123
+		return nil
+	}
+
+	router.POST("/logout/all", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.LogoutAll(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/logout/all", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !called {
+		t.Error("Expected LogoutAll to be called for user 123")
+	}
+}
+
+func TestUserController_ChangePassword_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.ChangePasswordFunc = func(userID int64, req *dto.ChangePasswordRequest) (*dto.TokenResponse, error) {
+		if userID != 123 || req.CurrentPassword != "oldpassword" || req.NewPassword != "newpassword" {
+			t.Errorf("Unexpected arguments: %d %+v", userID, req)
+		}
+		return &dto.TokenResponse{AccessToken: "access", RefreshToken: "refresh"}, nil
+	}
+
+	router.PUT("/user/password", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.ChangePassword(c)
+	})
+
+	jsonData, _ := json.Marshal(dto.ChangePasswordRequest{CurrentPassword: "oldpassword", NewPassword: "newpassword"})
+	req, _ := http.NewRequest("PUT", "/user/password", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var response dto.TokenResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.AccessToken != "access" || response.RefreshToken != "refresh" {
+		t.Errorf("Expected the new tokens, got %+v", response)
+	}
+}
+
+func TestUserController_ChangePassword_WrongPassword(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.ChangePasswordFunc = func(userID int64, req *dto.ChangePasswordRequest) (*dto.TokenResponse, error) {
+		return nil, service.ErrWrongPassword
+	}
+
+	router.PUT("/user/password", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.ChangePassword(c)
+	})
+
+	jsonData, _ := json.Marshal(dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newpassword"})
+	req, _ := http.NewRequest("PUT", "/user/password", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestUserController_ForgotPassword_Accepted(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	var requested string
+	mockService.RequestPasswordResetFunc = func(req *dto.ForgotPasswordRequest) error {
+		requested = req.Email
+		return nil
+	}
+
+	router.POST("/password/forgot", controller.ForgotPassword)
+
+	jsonData, _ := json.Marshal(dto.ForgotPasswordRequest{Email: "test@example.com"})
+	req, _ := http.NewRequest("POST", "/password/forgot", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected status %d, got %d", http.StatusAccepted, w.Code)
+	}
+	if requested != "test@example.com" {
+		t.Errorf("Expected a reset for test@example.com, got %q", requested)
+	}
+}
+
+func TestUserController_ResetPassword_InvalidToken(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.ResetPasswordFunc = func(req *dto.ResetPasswordRequest) error {
+		return service.ErrInvalidResetToken
+	}
+
+	router.POST("/password/reset", controller.ResetPassword)
+
+	jsonData, _ := json.Marshal(dto.ResetPasswordRequest{Token: "used", NewPassword: "newpassword"})
+	req, _ := http.NewRequest("POST", "/password/reset", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_ResetPassword_ShortPassword(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	router.POST("/password/reset", controller.ResetPassword)
+
+	jsonData, _ := json.Marshal(dto.ResetPasswordRequest{Token: "token", NewPassword: "short"})
+	req, _ := http.NewRequest("POST", "/password/reset", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_VerifyEmail_Success(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	var verified string
+	mockService.VerifyEmailFunc = func(req *dto.VerifyEmailRequest) error {
+		verified = req.Token
+		return nil
+	}
+
+	router.POST("/verify-email", controller.VerifyEmail)
+
+	jsonData, _ := json.Marshal(dto.VerifyEmailRequest{Token: "token"})
+	req, _ := http.NewRequest("POST", "/verify-email", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if verified != "token" {
+		t.Errorf("Expected the token to be verified, got %q", verified)
+	}
+}
+
+func TestUserController_VerifyEmail_InvalidToken(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.VerifyEmailFunc = func(req *dto.VerifyEmailRequest) error {
+		return service.ErrInvalidVerificationToken
+	}
+
+	router.POST("/verify-email", controller.VerifyEmail)
+
+	jsonData, _ := json.Marshal(dto.VerifyEmailRequest{Token: "expired"})
+	req, _ := http.NewRequest("POST", "/verify-email", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_ResendVerification_AlreadyVerified(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.ResendVerificationFunc = func(userID int64) error {
+		return service.ErrEmailAlreadyVerified
+	}
+
+	router.POST("/resend-verification", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.ResendVerification(c)
+	})
+
+	req, _ := http.NewRequest("POST", "/resend-verification", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestUserController_CreateAPIKey(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.CreateAPIKeyFunc = func(userID int64, req *dto.APIKeyCreateRequest) (*dto.APIKeyCreatedResponse, error) {
+		if userID != 123 || req.Name != "Home Assistant" {
+			t.Errorf("Unexpected request for user %d: %+v", userID, req)
+		}
+		return &dto.APIKeyCreatedResponse{
+			APIKeyResponse: dto.APIKeyResponse{ID: 1, Name: req.Name, Scopes: req.Scopes},
+			Key:            "plantie_KEY",
+		}, nil
+	}
+
+	router.POST("/user/api-keys", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.CreateAPIKey(c)
+	})
+
+	jsonData, _ := json.Marshal(dto.APIKeyCreateRequest{Name: "Home Assistant", Scopes: []string{"plants:read", "reminders:read"}})
+	req, _ := http.NewRequest("POST", "/user/api-keys", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var response dto.APIKeyCreatedResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Key != "plantie_KEY" || response.Name != "Home Assistant" {
+		t.Errorf("Unexpected response: %s", w.Body.String())
+	}
+}
+
+func TestUserController_CreateAPIKey_InvalidScope(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.CreateAPIKeyFunc = func(userID int64, req *dto.APIKeyCreateRequest) (*dto.APIKeyCreatedResponse, error) {
+		t.Error("Expected CreateAPIKey not to be called")
+		return nil, nil
+	}
+
+	router.POST("/user/api-keys", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.CreateAPIKey(c)
+	})
+
+	jsonData, _ := json.Marshal(dto.APIKeyCreateRequest{Name: "cron", Scopes: []string{"admin"}})
+	req, _ := http.NewRequest("POST", "/user/api-keys", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestUserController_DeleteAPIKey_NotFound(t *testing.T) {
+	mockService := &MockUserService{}
+	controller, router := setupUserController(mockService)
+
+	mockService.DeleteAPIKeyFunc = func(userID, keyID int64) error {
+		return service.ErrAPIKeyNotFound
+	}
+
+	router.DELETE("/user/api-keys/:keyId", func(c *gin.Context) {
+		c.Set("userID", int64(123))
+		controller.DeleteAPIKey(c)
+	})
+
+	req, _ := http.NewRequest("DELETE", "/user/api-keys/9", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"fmt"
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"strings"
+	"time"
+)
+
+type APIKeyCreateRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1"`
+}
+
+func (r *APIKeyCreateRequest) Validate() error {
+	if err := validate.Struct(r); err != nil {
+		return err
+	}
+	for _, scope := range r.Scopes {
+		if !constants.APIKeyScope(scope).IsValid() {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// APIKeyCreatedResponse is the only response that contains the key itself.
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+func (r *APIKeyResponse) FromModel(key *models.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     strings.Fields(key.Scopes),
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func FromAPIKeysModel(keys []models.APIKey) []APIKeyResponse {
+	responses := make([]APIKeyResponse, len(keys))
+	for i, key := range keys {
+		responses[i] = *(&APIKeyResponse{}).FromModel(&key)
+	}
+	return responses
+}
@@ -0,0 +1,88 @@
+package dto
+
+import (
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"time"
+)
+
+type CareEventRequest struct {
+	Note      string     `json:"note" validate:"omitempty,max=500"`
+	Timestamp *time.Time `json:"timestamp"`
+}
+
+type CareEventResponse struct {
+	ID         int64                `json:"id"`
+	ReminderID int64                `json:"reminderId"`
+	PlantID    int64                `json:"plantId"`
+	Action     constants.CareAction `json:"action"`
+	Note       string               `json:"note"`
+	Timestamp  time.Time            `json:"timestamp"`
+}
+
+type PlantHistoryResponse struct {
+	PlantID       int64               `json:"plantId"`
+	Streak        int                 `json:"streak"`
+	LastCompleted *time.Time          `json:"lastCompleted"`
+	Events        []CareEventResponse `json:"events"`
+}
+
+// OverdueTaskResponse is a missed reminder occurrence that hasn't been done or skipped since.
+type OverdueTaskResponse struct {
+	EventID    int64              `json:"eventId"`
+	ReminderID int64              `json:"reminderId"`
+	PlantID    int64              `json:"plantId"`
+	PlantName  string             `json:"plantName"`
+	TaskType   constants.TaskType `json:"taskType"`
+	TaskLabel  string             `json:"taskLabel,omitempty"`
+	MissedAt   time.Time          `json:"missedAt"`
+}
+
+func (r *CareEventRequest) ToModel(userID, plantID, reminderID int64, action constants.CareAction) *models.CareEvent {
+	timestamp := time.Now()
+	if r.Timestamp != nil {
+		timestamp = *r.Timestamp
+	}
+	return &models.CareEvent{
+		ReminderID: reminderID,
+		PlantID:    plantID,
+		UserID:     userID,
+		Action:     action,
+		Note:       r.Note,
+		Timestamp:  timestamp.UTC(),
+	}
+}
+
+func (r *CareEventResponse) FromModel(event *models.CareEvent) *CareEventResponse {
+	return &CareEventResponse{
+		ID:         event.ID,
+		ReminderID: event.ReminderID,
+		PlantID:    event.PlantID,
+		Action:     event.Action,
+		Note:       event.Note,
+		Timestamp:  event.Timestamp,
+	}
+}
+
+func FromCareEventsModel(events []models.CareEvent) []CareEventResponse {
+	responses := make([]CareEventResponse, len(events))
+	for i, event := range events {
+		responses[i] = *(&CareEventResponse{}).FromModel(&event)
+	}
+	return responses
+}
+
+func (r *OverdueTaskResponse) FromModel(event *models.CareEvent, reminder *models.Reminder) *OverdueTaskResponse {
+	response := &OverdueTaskResponse{
+		EventID:    event.ID,
+		ReminderID: event.ReminderID,
+		PlantID:    event.PlantID,
+		TaskType:   taskTypeOrDefault(reminder.TaskType),
+		TaskLabel:  reminder.TaskLabel,
+		MissedAt:   event.Timestamp,
+	}
+	if event.Plant != nil {
+		response.PlantName = event.Plant.Name
+	}
+	return response
+}
@@ -0,0 +1,32 @@
+package dto
+
+import (
+	"plant-reminder/models"
+	"time"
+)
+
+type DeviceResponse struct {
+	ID         int64     `json:"id"`
+	Platform   string    `json:"platform"`
+	AppVersion string    `json:"appVersion"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+func (r *DeviceResponse) FromModel(device *models.Device) *DeviceResponse {
+	return &DeviceResponse{
+		ID:         device.ID,
+		Platform:   device.Platform,
+		AppVersion: device.AppVersion,
+		LastSeenAt: device.LastSeenAt,
+		CreatedAt:  device.CreatedAt,
+	}
+}
+
+func FromDevicesModel(devices []models.Device) []DeviceResponse {
+	responses := make([]DeviceResponse, len(devices))
+	for i, device := range devices {
+		responses[i] = *(&DeviceResponse{}).FromModel(&device)
+	}
+	return responses
+}
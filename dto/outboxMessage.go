@@ -0,0 +1,45 @@
+package dto
+
+import (
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"time"
+)
+
+type OutboxMessageResponse struct {
+	ID            int64                  `json:"id"`
+	ReminderID    int64                  `json:"reminderId"`
+	UserID        int64                  `json:"userId"`
+	DeviceID      int64                  `json:"deviceId"`
+	Title         string                 `json:"title"`
+	Body          string                 `json:"body"`
+	Status        constants.OutboxStatus `json:"status"`
+	Attempts      int                    `json:"attempts"`
+	NextAttemptAt time.Time              `json:"nextAttemptAt"`
+	LastError     string                 `json:"lastError"`
+	CreatedAt     time.Time              `json:"createdAt"`
+}
+
+func (r *OutboxMessageResponse) FromModel(message *models.OutboxMessage) *OutboxMessageResponse {
+	return &OutboxMessageResponse{
+		ID:            message.ID,
+		ReminderID:    message.ReminderID,
+		UserID:        message.UserID,
+		DeviceID:      message.DeviceID,
+		Title:         message.Title,
+		Body:          message.Body,
+		Status:        message.Status,
+		Attempts:      message.Attempts,
+		NextAttemptAt: message.NextAttemptAt,
+		LastError:     message.LastError,
+		CreatedAt:     message.CreatedAt,
+	}
+}
+
+func FromOutboxMessagesModel(messages []models.OutboxMessage) []OutboxMessageResponse {
+	responses := make([]OutboxMessageResponse, len(messages))
+	for i, message := range messages {
+		responses[i] = *(&OutboxMessageResponse{}).FromModel(&message)
+	}
+	return responses
+}
@@ -1,68 +1,119 @@
 package dto
 
 import (
+	"fmt"
 	"plant-reminder/constants"
 	"plant-reminder/models"
+	"plant-reminder/utils"
 	"time"
 
 	"github.com/go-playground/validator"
 )
 
 type ReminderCreateRequest struct {
-	RepeatType constants.RepeatType `json:"repeatType"`
-	TimeOfDay  string               `json:"timeOfDay" validate:"required,len=5"`
-	DayOfWeek  *int16               `json:"dayOfWeek" validate:"omitempty,min=0,max=6"`
-	DayOfMonth *int16               `json:"dayOfMonth" validate:"omitempty,min=1,max=31"`
+	TaskType       constants.TaskType   `json:"taskType"`
+	TaskLabel      string               `json:"taskLabel" validate:"max=50"`
+	RepeatType     constants.RepeatType `json:"repeatType"`
+	TimeOfDay      string               `json:"timeOfDay" validate:"omitempty,len=5"`
+	DayOfWeek      *int16               `json:"dayOfWeek" validate:"omitempty,min=0,max=6"`
+	DaysOfWeek     []int16              `json:"daysOfWeek" validate:"omitempty,max=7"`
+	DayOfMonth     *int16               `json:"dayOfMonth" validate:"omitempty,min=1,max=31"`
+	Interval       *int16               `json:"interval"`
+	CronExpression string               `json:"cronExpression"`
 }
 
 type ReminderUpdateRequest struct {
-	ID         int64                `json:"id" validate:"required"`
-	RepeatType constants.RepeatType `json:"repeatType"`
-	TimeOfDay  string               `json:"timeOfDay" validate:"required,len=5"`
-	DayOfWeek  *int16               `json:"dayOfWeek" validate:"omitempty,min=0,max=6"`
-	DayOfMonth *int16               `json:"dayOfMonth" validate:"omitempty,min=1,max=31"`
+	ID             int64                `json:"id" validate:"required"`
+	TaskType       constants.TaskType   `json:"taskType"`
+	TaskLabel      string               `json:"taskLabel" validate:"max=50"`
+	RepeatType     constants.RepeatType `json:"repeatType"`
+	TimeOfDay      string               `json:"timeOfDay" validate:"omitempty,len=5"`
+	DayOfWeek      *int16               `json:"dayOfWeek" validate:"omitempty,min=0,max=6"`
+	DaysOfWeek     []int16              `json:"daysOfWeek" validate:"omitempty,max=7"`
+	DayOfMonth     *int16               `json:"dayOfMonth" validate:"omitempty,min=1,max=31"`
+	Interval       *int16               `json:"interval"`
+	CronExpression string               `json:"cronExpression"`
+}
+
+type ReminderSnoozeRequest struct {
+	Duration string `json:"duration" validate:"required"`
 }
 
 type ReminderResponse struct {
-	ID              int64                `json:"id"`
-	Repeat          constants.RepeatType `json:"repeatType"`
-	TimeOfDay       string               `json:"timeOfDay"`
-	NextTriggerTime time.Time            `json:"nextTriggerTime"`
-	Plant           *PlantResponse       `json:"plant,omitempty"`
-	DayOfWeek       *int16               `json:"dayOfWeek"`
-	DayOfMonth      *int16               `json:"dayOfMonth"`
+	ID                   int64                `json:"id"`
+	TaskType             constants.TaskType   `json:"taskType"`
+	TaskLabel            string               `json:"taskLabel,omitempty"`
+	Repeat               constants.RepeatType `json:"repeatType"`
+	TimeOfDay            string               `json:"timeOfDay"`
+	TimeZone             string               `json:"timeZone"`
+	NextTriggerTime      time.Time            `json:"nextTriggerTime"`
+	NextTriggerTimeLocal time.Time            `json:"nextTriggerTimeLocal"`
+	SnoozedUntil         *time.Time           `json:"snoozedUntil,omitempty"`
+	Plant                *PlantResponse       `json:"plant,omitempty"`
+	DayOfWeek            *int16               `json:"dayOfWeek"`
+	DaysOfWeek           []int16              `json:"daysOfWeek,omitempty"`
+	DayOfMonth           *int16               `json:"dayOfMonth"`
+	Interval             *int16               `json:"interval"`
+	CronExpression       string               `json:"cronExpression,omitempty"`
+	LastCompletedAt      *time.Time           `json:"lastCompletedAt"`
 }
 
 func (r *ReminderCreateRequest) ToModel(userID int64) *models.Reminder {
 	return &models.Reminder{
-		Repeat:     r.RepeatType,
-		TimeOfDay:  r.TimeOfDay,
-		UserID:     userID,
-		DayOfMonth: r.DayOfMonth,
-		DayOfWeek:  r.DayOfWeek,
+		TaskType:       taskTypeOrDefault(r.TaskType),
+		TaskLabel:      r.TaskLabel,
+		Repeat:         r.RepeatType,
+		TimeOfDay:      r.TimeOfDay,
+		UserID:         userID,
+		DayOfMonth:     r.DayOfMonth,
+		DayOfWeek:      r.DayOfWeek,
+		DaysOfWeek:     r.DaysOfWeek,
+		Interval:       r.Interval,
+		CronExpression: r.CronExpression,
 	}
 }
 
 func (r *ReminderUpdateRequest) ToModel(userID int64, plantId int64) *models.Reminder {
 	return &models.Reminder{
-		ID:         r.ID,
-		PlantID:    plantId,
-		Repeat:     r.RepeatType,
-		TimeOfDay:  r.TimeOfDay,
-		UserID:     userID,
-		DayOfMonth: r.DayOfMonth,
-		DayOfWeek:  r.DayOfWeek,
+		ID:             r.ID,
+		PlantID:        plantId,
+		TaskType:       taskTypeOrDefault(r.TaskType),
+		TaskLabel:      r.TaskLabel,
+		Repeat:         r.RepeatType,
+		TimeOfDay:      r.TimeOfDay,
+		UserID:         userID,
+		DayOfMonth:     r.DayOfMonth,
+		DayOfWeek:      r.DayOfWeek,
+		DaysOfWeek:     r.DaysOfWeek,
+		Interval:       r.Interval,
+		CronExpression: r.CronExpression,
 	}
 }
 
+// FromModel converts a reminder. The local trigger time uses the owner's time zone
+// when reminder.User is loaded and UTC otherwise.
 func (r *ReminderResponse) FromModel(reminder *models.Reminder) *ReminderResponse {
+	loc := time.UTC
+	if reminder.User != nil {
+		loc = utils.LoadLocation(reminder.User.TimeZone)
+	}
+
 	response := &ReminderResponse{
-		ID:              reminder.ID,
-		Repeat:          reminder.Repeat,
-		TimeOfDay:       reminder.TimeOfDay,
-		NextTriggerTime: reminder.NextTriggerTime,
-		DayOfMonth:      reminder.DayOfMonth,
-		DayOfWeek:       reminder.DayOfWeek,
+		ID:                   reminder.ID,
+		TaskType:             taskTypeOrDefault(reminder.TaskType),
+		TaskLabel:            reminder.TaskLabel,
+		Repeat:               reminder.Repeat,
+		TimeOfDay:            reminder.TimeOfDay,
+		TimeZone:             loc.String(),
+		NextTriggerTime:      reminder.NextTriggerTime.UTC(),
+		NextTriggerTimeLocal: reminder.NextTriggerTime.In(loc),
+		SnoozedUntil:         reminder.SnoozedUntil,
+		DayOfMonth:           reminder.DayOfMonth,
+		DayOfWeek:            reminder.DayOfWeek,
+		DaysOfWeek:           reminder.DaysOfWeek,
+		Interval:             reminder.Interval,
+		CronExpression:       reminder.CronExpression,
+		LastCompletedAt:      reminder.LastCompletedAt,
 	}
 
 	if reminder.Plant != nil {
@@ -80,18 +131,80 @@ func FromRemindersModel(reminders []models.Reminder) []ReminderResponse {
 	return responses
 }
 
+// taskTypeOrDefault keeps watering the default task, as it was before task types existed.
+func taskTypeOrDefault(taskType constants.TaskType) constants.TaskType {
+	if taskType == "" {
+		return constants.TaskWater
+	}
+	return taskType
+}
+
 var validate = validator.New()
 
+const (
+	minSnooze = time.Minute
+	maxSnooze = 24 * time.Hour
+)
+
+// ParseDuration validates the request and returns the snooze duration, e.g. "30m" or "2h".
+func (r *ReminderSnoozeRequest) ParseDuration() (time.Duration, error) {
+	if err := validate.Struct(r); err != nil {
+		return 0, err
+	}
+	duration, err := time.ParseDuration(r.Duration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration: %w", err)
+	}
+	if duration < minSnooze || duration > maxSnooze {
+		return 0, fmt.Errorf("duration must be between %s and %s", minSnooze, maxSnooze)
+	}
+	return duration, nil
+}
+
 func (r *ReminderCreateRequest) Validate() error {
 	if err := validate.Struct(r); err != nil {
 		return err
 	}
-	return constants.ValidateReminderFields(r.RepeatType, r.DayOfWeek, r.DayOfMonth)
+	if err := constants.ValidateTask(taskTypeOrDefault(r.TaskType), r.TaskLabel); err != nil {
+		return err
+	}
+	return validateSchedule(r.RepeatType, constants.ScheduleFields{
+		TimeOfDay:      r.TimeOfDay,
+		DayOfWeek:      r.DayOfWeek,
+		DaysOfWeek:     r.DaysOfWeek,
+		DayOfMonth:     r.DayOfMonth,
+		Interval:       r.Interval,
+		CronExpression: r.CronExpression,
+	})
 }
 
 func (r *ReminderUpdateRequest) Validate() error {
 	if err := validate.Struct(r); err != nil {
 		return err
 	}
-	return constants.ValidateReminderFields(r.RepeatType, r.DayOfWeek, r.DayOfMonth)
+	if err := constants.ValidateTask(taskTypeOrDefault(r.TaskType), r.TaskLabel); err != nil {
+		return err
+	}
+	return validateSchedule(r.RepeatType, constants.ScheduleFields{
+		TimeOfDay:      r.TimeOfDay,
+		DayOfWeek:      r.DayOfWeek,
+		DaysOfWeek:     r.DaysOfWeek,
+		DayOfMonth:     r.DayOfMonth,
+		Interval:       r.Interval,
+		CronExpression: r.CronExpression,
+	})
+}
+
+// validateSchedule checks the schedule fields of a reminder request, including that
+// a cron expression parses.
+func validateSchedule(repeatType constants.RepeatType, fields constants.ScheduleFields) error {
+	if err := constants.ValidateReminderFields(repeatType, fields); err != nil {
+		return err
+	}
+	if repeatType == constants.RepeatCron {
+		if _, err := utils.ParseCronExpression(fields.CronExpression); err != nil {
+			return err
+		}
+	}
+	return nil
 }
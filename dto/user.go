@@ -9,10 +9,12 @@ type UserCreateRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
 	Name     string `json:"name" validate:"omitempty,min=2,max=100"`
+	TimeZone string `json:"timeZone" validate:"omitempty,timezone"`
 }
 
 type UserUpdateRequest struct {
-	Name string `json:"name" validate:"omitempty,min=2,max=100"`
+	Name     string `json:"name" validate:"omitempty,min=2,max=100"`
+	TimeZone string `json:"timeZone" validate:"omitempty,timezone"`
 }
 
 type UserLoginRequest struct {
@@ -20,16 +22,101 @@ type UserLoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,min=6"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=6"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TwoFactorChallenge is what /login returns instead of tokens when the user has
+// two-factor authentication on. The challenge token is exchanged at /login/2fa.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+}
+
+// OIDCLoginRequest carries the result of the authorization code flow the app ran
+// with a login provider.
+type OIDCLoginRequest struct {
+	Code        string `json:"code" validate:"required,max=2048"`
+	RedirectURI string `json:"redirectUri" validate:"required,max=2048"`
+	// CodeVerifier is the PKCE verifier, if the app used PKCE.
+	CodeVerifier string `json:"codeVerifier" validate:"max=128"`
+	// Nonce is the nonce the app put into the authorization request; the ID
+	// token must carry the same one.
+	Nonce string `json:"nonce" validate:"required,max=256"`
+	// TimeZone is used if the login creates an account.
+	TimeZone string `json:"timeZone" validate:"omitempty,timezone"`
+}
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challengeToken" validate:"required"`
+	// Code is a code from the authenticator app or a recovery code.
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
 type UserResponse struct {
-	ID           int64           `json:"id"`
-	Email        string          `json:"email"`
-	Name         string          `json:"name"`
-	CreationDate time.Time       `json:"createdAt"`
-	Plants       []PlantResponse `json:"plants,omitempty"`
+	ID               int64           `json:"id"`
+	Email            string          `json:"email"`
+	EmailVerified    bool            `json:"emailVerified"`
+	TwoFactorEnabled bool            `json:"twoFactorEnabled"`
+	Name             string          `json:"name"`
+	TimeZone         string          `json:"timeZone"`
+	CreationDate     time.Time       `json:"createdAt"`
+	Plants           []PlantResponse `json:"plants,omitempty"`
 }
 
 type PushTokenRequest struct {
-	Token string `json:"token" validate:"required"`
+	Token      string `json:"token" validate:"required"`
+	Platform   string `json:"platform" validate:"omitempty,oneof=ios android web"`
+	AppVersion string `json:"appVersion" validate:"omitempty,max=50"`
+}
+
+func (r *PushTokenRequest) ToModel(userID int64) *models.Device {
+	return &models.Device{
+		UserID:     userID,
+		Token:      r.Token,
+		Platform:   r.Platform,
+		AppVersion: r.AppVersion,
+	}
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type AuthResponse struct {
@@ -39,19 +126,27 @@ type AuthResponse struct {
 }
 
 func (r *UserCreateRequest) ToModel() *models.User {
+	timeZone := r.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
 	return &models.User{
 		Email:    r.Email,
 		Password: r.Password,
 		Name:     r.Name,
+		TimeZone: timeZone,
 	}
 }
 
 func (r *UserResponse) FromModel(user *models.User) *UserResponse {
 	response := &UserResponse{
-		ID:           user.ID,
-		Email:        user.Email,
-		Name:         user.Name,
-		CreationDate: user.CreationDate,
+		ID:               user.ID,
+		Email:            user.Email,
+		EmailVerified:    user.EmailVerified,
+		TwoFactorEnabled: user.TOTPEnabled,
+		Name:             user.Name,
+		TimeZone:         user.TimeZone,
+		CreationDate:     user.CreationDate,
 	}
 
 	if user.Plants != nil {
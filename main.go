@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
@@ -10,44 +14,79 @@ import (
 
 	"plant-reminder/config"
 	"plant-reminder/container"
-	"plant-reminder/models"
+	"plant-reminder/migrations"
 	"plant-reminder/routes"
 	"plant-reminder/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
-	"gorm.io/gorm"
 )
 
 func main() {
 	loadEnv()
 
-	app := initApp()
+	if len(os.Args) > 1 && os.Args[1] == "help" {
+		fmt.Print(commandUsage)
+		return
+	}
+
+	cfg, args := loadConfig()
+
+	if len(args) > 0 {
+		runCommand(cfg, args)
+		return
+	}
+
+	app := initApp(cfg)
 
 	server := setupServer(app)
 	startServer(server)
 	gracefulShutdown(server)
 }
 
+// loadEnv loads the optional .env file, except in production.
 func loadEnv() {
-	if os.Getenv("ENV") != "production" {
-		if err := godotenv.Load(); err != nil {
-			log.Fatal("Error loading .env file")
-		}
+	if os.Getenv("ENV") == "production" {
+		return
+	}
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("Error loading .env file: %v", err)
 	}
 }
 
-func initApp() *container.Application {
-	initDatabase()
+// loadConfig reads and validates the configuration, applies the settings of the
+// packages that aren't wired through the container and returns the command to run.
+func loadConfig() (*config.Config, []string) {
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
 
-	// Give the database a moment to settle before running migrations
-	time.Sleep(1 * time.Second)
+	utils.InitTokens(cfg.Auth.JWTKey, cfg.Auth.AccessTokenTTL, cfg.Auth.RefreshTokenTTL)
+	if cfg.Auth.JWTKeyDir != "" {
+		if err := utils.LoadTokenKeys(cfg.Auth.JWTKeyDir, cfg.Auth.JWTSigningKey); err != nil {
+			log.Fatalf("Failed to load JWT keys: %v", err)
+		}
+	}
+	utils.SetPasswordCost(cfg.Auth.BcryptCost)
 
-	runMigrations()
-	initNotifier()
+	return cfg, args
+}
 
-	app := container.NewApplication()
+func initApp(cfg *config.Config) *container.Application {
+	if cfg.Auth.JWTKeyDir != "" && cfg.Auth.JWTKey != "" {
+		log.Printf("JWT_KEY still accepts HS256 tokens; remove it once the refresh token TTL has passed since switching to JWT_KEY_DIR")
+	}
+	initDatabase(cfg)
+	checkSchema()
+	notifier := initNotifier(cfg.Notifier)
+	mailer := initMailer(cfg.Mailer)
+
+	app := container.NewApplication(cfg, notifier, mailer)
 
 	setupCrons(app)
 
@@ -56,16 +95,14 @@ func initApp() *container.Application {
 
 func setupServer(app *container.Application) *http.Server {
 	router := gin.Default()
+	if err := router.SetTrustedProxies(app.Config.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
 	router.Use(cors.Default())
 	routes.SetupRouter(router, app)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
 	return &http.Server{
-		Addr:    ":" + port,
+		Addr:    ":" + app.Config.Port,
 		Handler: router,
 	}
 }
@@ -97,19 +134,18 @@ func gracefulShutdown(server *http.Server) {
 	log.Println("Server exited cleanly")
 }
 
-func initDatabase() {
-	config.InitDb()
+func initDatabase(cfg *config.Config) {
+	config.InitDb(cfg.Database)
 }
 
-func runMigrations() {
-	err := config.DB.AutoMigrate(&models.User{}, &models.Plant{}, &models.Reminder{})
+// checkSchema refuses to start the server on a database that is missing migrations.
+func checkSchema() {
+	pending, err := migrations.Pending(config.DB)
 	if err != nil {
-		log.Printf("Migration warning: %v", err)
-	} else {
-		Session := config.DB.Session(&gorm.Session{PrepareStmt: true})
-		if Session != nil {
-			log.Println("Database migration completed successfully")
-		}
+		log.Fatalf("Failed to check database schema: %v", err)
+	}
+	if len(pending) > 0 {
+		log.Fatalf("Database schema is behind by %d migration(s), run `migrate up` first", len(pending))
 	}
 }
 
@@ -119,8 +155,32 @@ func setupCrons(app *container.Application) {
 	}
 }
 
-func initNotifier() {
-	if err := utils.InitNotifier(); err != nil {
+func initNotifier(cfg config.NotifierConfig) utils.Notifier {
+	if cfg.Kind == config.NotifierLog {
+		log.Println("Using log notifier, push notifications will not be delivered")
+		return utils.NewLogNotifier()
+	}
+
+	notifier, err := utils.NewFCMNotifier(cfg.FirebasePath)
+	if err != nil {
 		log.Fatalf("Failed to init notifier: %v", err)
 	}
+	return notifier
+}
+
+func initMailer(cfg config.MailerConfig) utils.Mailer {
+	switch cfg.Kind {
+	case config.MailerSMTP:
+		mailer, err := utils.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+		if err != nil {
+			log.Fatalf("Failed to init mailer: %v", err)
+		}
+		return mailer
+	case config.MailerFile:
+		log.Printf("Using file mailer, emails are written to %s", cfg.Dir)
+		return utils.NewFileMailer(cfg.Dir, cfg.From)
+	default:
+		log.Println("Using log mailer, emails will not be delivered")
+		return utils.NewLogMailer()
+	}
 }
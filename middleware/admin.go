@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyAdmin protects operational endpoints with the shared admin token, sent in the
+// X-Admin-Token header. The endpoints are disabled when the token is empty.
+func VerifyAdmin(adminToken string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if adminToken == "" {
+			ctx.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "not found"})
+			return
+		}
+
+		token := ctx.GetHeader("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid admin token"})
+			return
+		}
+	}
+}
@@ -1,42 +1,126 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
+	"plant-reminder/constants"
+	"plant-reminder/repository"
+	"plant-reminder/service"
 	"plant-reminder/utils"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func VerifyAuth(ctx *gin.Context) {
-	authHeader := ctx.GetHeader("Authorization")
-	if !strings.HasPrefix(authHeader, "Bearer ") {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid authorization header"})
-		return
+// apiKeyScopesKey is where VerifyAuth puts the scopes of an API key. It is unset
+// for access tokens, which may use every route.
+const apiKeyScopesKey = "apiKeyScopes"
+
+// Credentials checks the credentials VerifyAuth accepts.
+type Credentials interface {
+	// TokenVersion looks up the current token version of a user.
+	TokenVersion(userID int64) (int, error)
+	// AuthenticateAPIKey returns the owner and the scopes of an API key.
+	AuthenticateAPIKey(key string) (int64, []string, error)
+}
+
+// VerifyAuth accepts access tokens whose version matches the user's current token
+// version, so tokens of deleted users and of users who logged out everywhere are
+// rejected before they expire. It also accepts API keys, whose scopes RequireScope
+// checks.
+func VerifyAuth(credentials Credentials) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authHeader := ctx.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid authorization header"})
+			return
+		}
+		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		if strings.HasPrefix(tokenString, utils.APIKeyPrefix) {
+			verifyAPIKey(ctx, credentials, tokenString)
+			return
+		}
+
+		token, err := utils.VerifyPayload(tokenString)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid authorization header"})
+			return
+		}
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok || !token.Valid {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid token claims"})
+			return
+		}
+
+		if tokenType, exists := claims["type"]; !exists || tokenType != "access" {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid token type"})
+			return
+		}
+
+		userID, ok := claims["userID"].(float64)
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid userID in token"})
+			return
+		}
+
+		// Tokens issued before versioning carry no version and count as version 0.
+		tokenVersion, _ := claims["ver"].(float64)
+		currentVersion, err := credentials.TokenVersion(int64(userID))
+		if errors.Is(err, repository.ErrNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "user not found"})
+			return
+		}
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to verify token"})
+			return
+		}
+		if int(tokenVersion) != currentVersion {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "token has been revoked"})
+			return
+		}
+
+		ctx.Set("userID", int64(userID))
 	}
-	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	token, err := utils.VerifyPayload(tokenString)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid authorization header"})
+}
+
+func verifyAPIKey(ctx *gin.Context, credentials Credentials, key string) {
+	userID, scopes, err := credentials.AuthenticateAPIKey(key)
+	if errors.Is(err, service.ErrInvalidAPIKey) {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid API key"})
 		return
 	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid token claims"})
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "failed to verify API key"})
 		return
 	}
 
-	if tokenType, exists := claims["type"]; !exists || tokenType != "access" {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid token type"})
-		return
-	}
+	ctx.Set("userID", userID)
+	ctx.Set(apiKeyScopesKey, scopes)
+}
 
-	userID, ok := claims["userID"].(float64)
-	if !ok {
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "invalid userID in token"})
-		return
+// RequireScope limits API keys to the routes their scopes allow.
+func RequireScope(scope constants.APIKeyScope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scopes, isAPIKey := ctx.Get(apiKeyScopesKey)
+		if !isAPIKey {
+			return
+		}
+		if !slices.Contains(scopes.([]string), string(scope)) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "API key lacks the " + string(scope) + " scope"})
+			return
+		}
 	}
+}
 
-	ctx.Set("userID", int64(userID))
+// RequireSession keeps API keys out of account management, such as changing the
+// password or creating more keys.
+func RequireSession() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, isAPIKey := ctx.Get(apiKeyScopesKey); isAPIKey {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "not available to API keys"})
+			return
+		}
+	}
 }
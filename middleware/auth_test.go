@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"plant-reminder/constants"
+	"plant-reminder/repository"
+	"plant-reminder/service"
+	"plant-reminder/utils"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MockCredentials struct {
+	TokenVersionFunc       func(int64) (int, error)
+	AuthenticateAPIKeyFunc func(string) (int64, []string, error)
+}
+
+func (m *MockCredentials) TokenVersion(userID int64) (int, error) {
+	if m.TokenVersionFunc != nil {
+		return m.TokenVersionFunc(userID)
+	}
+	return 0, nil
+}
+
+func (m *MockCredentials) AuthenticateAPIKey(key string) (int64, []string, error) {
+	if m.AuthenticateAPIKeyFunc != nil {
+		return m.AuthenticateAPIKeyFunc(key)
+	}
+	return 0, nil, nil
+}
+
+// setupAuthRouter groups routes behind VerifyAuth like the application router does.
+// Every route echoes the authenticated user.
+func setupAuthRouter(credentials Credentials) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	utils.InitTokens("test-secret", time.Minute, time.Hour)
+
+	echoUser := func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"userID": ctx.GetInt64("userID")})
+	}
+	router := gin.New()
+	authGroup := router.Group("/", VerifyAuth(credentials))
+	accountGroup := authGroup.Group("/", RequireSession())
+	accountGroup.GET("/user/api-keys", echoUser)
+	plantsRead := authGroup.Group("/", RequireScope(constants.ScopePlantsRead))
+	plantsWrite := authGroup.Group("/", RequireScope(constants.ScopePlantsWrite))
+	plantsRead.GET("/plants", echoUser)
+	plantsWrite.POST("/plant", echoUser)
+	return router
+}
+
+// apiKeyCredentials accepts the single key "plantie_valid" with the given scopes.
+func apiKeyCredentials(scopes ...constants.APIKeyScope) *MockCredentials {
+	return &MockCredentials{
+		AuthenticateAPIKeyFunc: func(key string) (int64, []string, error) {
+			if key != "plantie_valid" {
+				return 0, nil, service.ErrInvalidAPIKey
+			}
+			var granted []string
+			for _, scope := range scopes {
+				granted = append(granted, string(scope))
+			}
+			return 7, granted, nil
+		},
+	}
+}
+
+func sendAuthorized(router *gin.Engine, method string, path string, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func assertError(t *testing.T, w *httptest.ResponseRecorder, status int, message string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("Expected status %d, got %d: %s", status, w.Code, w.Body.String())
+	}
+	var response map[string]string
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["message"] != message {
+		t.Errorf("Expected message %q, got %q", message, response["message"])
+	}
+}
+
+func TestVerifyAuth_AccessToken(t *testing.T) {
+	router := setupAuthRouter(&MockCredentials{
+		TokenVersionFunc: func(userID int64) (int, error) {
+			return 2, nil
+		},
+	})
+	token, err := utils.SignPayload(7, 2)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+
+	w := sendAuthorized(router, "GET", "/plants", token)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response map[string]int64
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response["userID"] != 7 {
+		t.Errorf("Expected userID 7, got %d", response["userID"])
+	}
+}
+
+func TestVerifyAuth_OutdatedTokenVersion(t *testing.T) {
+	router := setupAuthRouter(&MockCredentials{
+		TokenVersionFunc: func(userID int64) (int, error) {
+			return 3, nil
+		},
+	})
+	token, err := utils.SignPayload(7, 2)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+
+	w := sendAuthorized(router, "GET", "/plants", token)
+
+	assertError(t, w, http.StatusUnauthorized, "token has been revoked")
+}
+
+func TestVerifyAuth_DeletedUser(t *testing.T) {
+	router := setupAuthRouter(&MockCredentials{
+		TokenVersionFunc: func(userID int64) (int, error) {
+			return 0, repository.ErrNotFound
+		},
+	})
+	token, err := utils.SignPayload(7, 0)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+
+	w := sendAuthorized(router, "GET", "/plants", token)
+
+	assertError(t, w, http.StatusUnauthorized, "user not found")
+}
+
+func TestVerifyAuth_APIKeyScopes(t *testing.T) {
+	router := setupAuthRouter(apiKeyCredentials(constants.ScopePlantsRead))
+
+	if w := sendAuthorized(router, "GET", "/plants", "plantie_valid"); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with plants:read, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := sendAuthorized(router, "POST", "/plant", "plantie_valid")
+	assertError(t, w, http.StatusForbidden, "API key lacks the plants:write scope")
+}
+
+func TestVerifyAuth_APIKeyRequiresSession(t *testing.T) {
+	router := setupAuthRouter(apiKeyCredentials(constants.ScopePlantsRead, constants.ScopePlantsWrite))
+
+	w := sendAuthorized(router, "GET", "/user/api-keys", "plantie_valid")
+
+	assertError(t, w, http.StatusForbidden, "not available to API keys")
+}
+
+func TestVerifyAuth_RevokedAPIKey(t *testing.T) {
+	router := setupAuthRouter(apiKeyCredentials(constants.ScopePlantsRead))
+
+	w := sendAuthorized(router, "GET", "/plants", "plantie_revoked")
+
+	assertError(t, w, http.StatusUnauthorized, "invalid API key")
+}
+
+func TestVerifyAuth_AccessTokenSkipsScopes(t *testing.T) {
+	router := setupAuthRouter(&MockCredentials{})
+	token, err := utils.SignPayload(7, 0)
+	if err != nil {
+		t.Fatalf("SignPayload failed: %v", err)
+	}
+
+	for _, route := range []struct{ method, path string }{
+		{"GET", "/plants"},
+		{"POST", "/plant"},
+		{"GET", "/user/api-keys"},
+	} {
+		if w := sendAuthorized(router, route.method, route.path, token); w.Code != http.StatusOK {
+			t.Errorf("%s %s: expected status 200, got %d: %s", route.method, route.path, w.Code, w.Body.String())
+		}
+	}
+}
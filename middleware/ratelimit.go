@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"plant-reminder/ratelimit"
+	"plant-reminder/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxKeyBodySize bounds how much of a request body JSONField reads.
+const maxKeyBodySize = 64 << 10
+
+// RateLimit rejects requests with 429 and a Retry-After header once the bucket of
+// their key is empty. Requests without a key are not limited. If the store fails,
+// requests are let through rather than locking everybody out.
+func RateLimit(limiter *ratelimit.Limiter, key func(ctx *gin.Context) string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		k := key(ctx)
+		if k == "" {
+			return
+		}
+
+		wait, err := limiter.Allow(k)
+		if err != nil {
+			log.Printf("RateLimit: failed to take a token: %v", err)
+			return
+		}
+		if wait > 0 {
+			ctx.Header("Retry-After", utils.RetryAfter(wait))
+			ctx.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "too many requests"})
+			return
+		}
+	}
+}
+
+// ClientIP keys requests by the client address. Forwarding headers are only
+// trusted from the proxies set with gin's SetTrustedProxies.
+func ClientIP(ctx *gin.Context) string {
+	return ctx.ClientIP()
+}
+
+// UserID keys requests by the user authenticated by VerifyAuth.
+func UserID(ctx *gin.Context) string {
+	userID := ctx.GetInt64("userID")
+	if userID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(userID, 10)
+}
+
+// JSONField keys requests by a string field of their JSON body, e.g. the email of a
+// login. The body is left in place for the handler.
+func JSONField(name string) func(ctx *gin.Context) string {
+	return func(ctx *gin.Context) string {
+		if ctx.Request.Body == nil {
+			return ""
+		}
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxKeyBodySize))
+		if err != nil {
+			return ""
+		}
+		ctx.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), ctx.Request.Body))
+
+		var fields map[string]any
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return ""
+		}
+		value, _ := fields[name].(string)
+		return strings.ToLower(strings.TrimSpace(value))
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"plant-reminder/config"
+	"plant-reminder/migrations"
+)
+
+// runMigrateCommand implements the `migrate` subcommand.
+func runMigrateCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal(commandUsage)
+	}
+
+	initDatabase(cfg)
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(config.DB)
+		for _, migration := range applied {
+			fmt.Printf("applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("steps must be a positive integer, got %q", args[1])
+			}
+			steps = n
+		}
+		rolledBack, err := migrations.Down(config.DB, steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("no applied migrations")
+		}
+	case "status":
+		status, err := migrations.Status(config.DB)
+		if err != nil {
+			log.Fatalf("Failed to read migration status: %v", err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, migration := range status {
+			appliedAt := "pending"
+			if migration.AppliedAt != nil {
+				appliedAt = migration.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", migration.Version, migration.Name, appliedAt)
+		}
+		w.Flush()
+	default:
+		log.Fatal(commandUsage)
+	}
+}
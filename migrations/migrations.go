@@ -0,0 +1,215 @@
+// Package migrations applies the versioned SQL scripts embedded in the binary.
+//
+// Every driver has its own directory of scripts named <version>_<name>.up.sql and
+// <version>_<name>.down.sql. Applied versions are recorded in the schema_migrations
+// table; each script runs in a transaction together with its record.
+//
+// Scripts may use ALTER TABLE ... ADD COLUMN IF NOT EXISTS on every driver. SQLite
+// lacks it, so there the runner skips columns that exist and adds the others.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var scripts embed.FS
+
+type Migration struct {
+	Version   int64
+	Name      string
+	Up        string
+	Down      string
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load returns the migrations of the driver ordered by version.
+func Load(driver string) ([]Migration, error) {
+	files, err := fs.ReadDir(scripts, driver)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %s", driver)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, file := range files {
+		base, direction, ok := strings.Cut(strings.TrimSuffix(file.Name(), ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("invalid migration file name: %s", file.Name())
+		}
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version: %s", file.Name())
+		}
+
+		script, err := fs.ReadFile(scripts, path.Join(driver, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(script)
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Status returns all migrations of the database's driver. AppliedAt is nil for the
+// pending ones.
+func Status(db *gorm.DB) ([]Migration, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	if err := createSchemaTable(db); err != nil {
+		return nil, err
+	}
+
+	var applied []schemaMigration
+	if err := db.Find(&applied).Error; err != nil {
+		return nil, err
+	}
+	appliedAt := make(map[int64]time.Time, len(applied))
+	for _, record := range applied {
+		appliedAt[record.Version] = record.AppliedAt
+	}
+
+	for i := range migrations {
+		if at, ok := appliedAt[migrations[i].Version]; ok {
+			migrations[i].AppliedAt = &at
+		}
+	}
+	return migrations, nil
+}
+
+// Pending returns the migrations that haven't been applied yet.
+func Pending(db *gorm.DB) ([]Migration, error) {
+	migrations, err := Status(db)
+	if err != nil {
+		return nil, err
+	}
+	pending := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.AppliedAt == nil {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies all pending migrations in order and returns them. It stops at the first
+// failing one; the migrations before it stay applied.
+func Up(db *gorm.DB) ([]Migration, error) {
+	pending, err := Pending(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(addMissingColumns(tx, pending[i].Up)).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{
+				Version:   pending[i].Version,
+				Name:      pending[i].Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %04d_%s failed: %w", pending[i].Version, pending[i].Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, and returns them.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	migrations, err := Status(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []Migration
+	for i := len(migrations) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		migration := migrations[i]
+		if migration.AppliedAt == nil {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("rollback of %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration)
+	}
+	return rolledBack, nil
+}
+
+// addColumnIfNotExists matches an ALTER TABLE ... ADD COLUMN IF NOT EXISTS statement
+// and captures the table, the column and the column definition.
+var addColumnIfNotExists = regexp.MustCompile(`(?i)ALTER TABLE\s+(\w+)\s+ADD COLUMN IF NOT EXISTS\s+"?(\w+)"?([^;]*);`)
+
+// addMissingColumns emulates ADD COLUMN IF NOT EXISTS for SQLite: statements for
+// existing columns are dropped from the script, the others become plain ADD COLUMN.
+func addMissingColumns(tx *gorm.DB, script string) string {
+	if tx.Dialector.Name() != "sqlite" {
+		return script
+	}
+	return addColumnIfNotExists.ReplaceAllStringFunc(script, func(statement string) string {
+		match := addColumnIfNotExists.FindStringSubmatch(statement)
+		table, column, definition := match[1], match[2], match[3]
+		if tx.Migrator().HasColumn(table, column) {
+			return ""
+		}
+		return fmt.Sprintf(`ALTER TABLE %s ADD COLUMN "%s"%s;`, table, column, definition)
+	})
+}
+
+func createSchemaTable(db *gorm.DB) error {
+	return db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at TIMESTAMP NOT NULL
+	)`).Error
+}
@@ -0,0 +1,164 @@
+package migrations
+
+import (
+	"plant-reminder/config"
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := config.OpenDb(config.DatabaseConfig{Driver: config.DriverSQLite, URL: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	return db
+}
+
+func TestLoad_PairsScripts(t *testing.T) {
+	for _, driver := range []string{config.DriverPostgres, config.DriverSQLite} {
+		migrations, err := Load(driver)
+		if err != nil {
+			t.Fatalf("Load(%s) failed: %v", driver, err)
+		}
+		if len(migrations) == 0 || migrations[0].Version != 1 || migrations[0].Name != "initial" {
+			t.Errorf("Unexpected %s migrations: %+v", driver, migrations)
+		}
+	}
+}
+
+func TestUpDown_RoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+
+	pending, err := Pending(db)
+	if err != nil || len(pending) == 0 {
+		t.Fatalf("Expected pending migrations on an empty database, got %d (%v)", len(pending), err)
+	}
+
+	applied, err := Up(db)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if len(applied) != len(pending) {
+		t.Errorf("Expected %d applied migrations, got %d", len(pending), len(applied))
+	}
+	if pending, _ := Pending(db); len(pending) != 0 {
+		t.Errorf("Expected no pending migrations after Up, got %d", len(pending))
+	}
+	if applied, _ := Up(db); len(applied) != 0 {
+		t.Errorf("Expected a second Up to be a no-op, applied %d", len(applied))
+	}
+
+	if err := db.Create(&models.User{Email: "test@example.com"}).Error; err != nil {
+		t.Errorf("Failed to use the migrated schema: %v", err)
+	}
+
+	rolledBack, err := Down(db, len(applied))
+	if err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if len(rolledBack) != len(applied) {
+		t.Errorf("Expected %d rolled back migrations, got %d", len(applied), len(rolledBack))
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("Expected the users table to be dropped")
+	}
+}
+
+// The models as they were before versioned migrations; AutoMigrate created the
+// schema of existing databases from them.
+type baselineUser struct {
+	ID           int64 `gorm:"primaryKey"`
+	Email        string
+	Password     string
+	Name         string
+	CreationDate time.Time
+	PushToken    string
+}
+
+func (baselineUser) TableName() string {
+	return "users"
+}
+
+type baselinePlant struct {
+	ID        int64 `gorm:"primaryKey"`
+	Name      string
+	Note      string
+	TagColor  string
+	UserID    int64
+	PlantIcon string
+}
+
+func (baselinePlant) TableName() string {
+	return "plants"
+}
+
+type baselineReminder struct {
+	ID              int64 `gorm:"primaryKey"`
+	PlantID         int64
+	Repeat          constants.RepeatType `gorm:"type:smallint"`
+	TimeOfDay       string
+	NextTriggerTime time.Time
+	UserID          int64
+	DayOfWeek       *int16
+	DayOfMonth      *int16
+}
+
+func (baselineReminder) TableName() string {
+	return "reminders"
+}
+
+func TestUp_AdoptsAutoMigratedSchema(t *testing.T) {
+	db := setupTestDB(t)
+	// Databases from before versioned migrations have the baseline schema, but no
+	// schema_migrations table.
+	if err := db.AutoMigrate(&baselineUser{}, &baselinePlant{}, &baselineReminder{}); err != nil {
+		t.Fatalf("Failed to create the baseline schema: %v", err)
+	}
+	user := &baselineUser{Email: "old@example.com", Password: "hash", CreationDate: time.Now(), PushToken: "legacy-token"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	plant := &baselinePlant{Name: "Monstera", UserID: user.ID}
+	if err := db.Create(plant).Error; err != nil {
+		t.Fatalf("Failed to create plant: %v", err)
+	}
+	legacy := &baselineReminder{PlantID: plant.ID, UserID: user.ID, Repeat: constants.RepeatDaily, TimeOfDay: "08:00", NextTriggerTime: time.Now()}
+	if err := db.Create(legacy).Error; err != nil {
+		t.Fatalf("Failed to create reminder: %v", err)
+	}
+
+	if _, err := Up(db); err != nil {
+		t.Fatalf("Up failed on an existing schema: %v", err)
+	}
+
+	var migrated models.User
+	if err := db.First(&migrated, user.ID).Error; err != nil {
+		t.Fatalf("Failed to load the user: %v", err)
+	}
+	if migrated.TimeZone != "UTC" {
+		t.Errorf("Expected the time zone to default to UTC, got %q", migrated.TimeZone)
+	}
+	var reminder models.Reminder
+	if err := db.First(&reminder, legacy.ID).Error; err != nil {
+		t.Fatalf("Failed to load the reminder: %v", err)
+	}
+	if reminder.TaskType != constants.TaskWater {
+		t.Errorf("Expected existing reminders to water, got %q", reminder.TaskType)
+	}
+	if err := db.Create(&models.Reminder{PlantID: plant.ID, UserID: user.ID, TaskLabel: "Mist", CronExpression: "0 9 * * *"}).Error; err != nil {
+		t.Errorf("Failed to create a reminder with the migrated schema: %v", err)
+	}
+
+	var device models.Device
+	if err := db.Where("user_id = ?", user.ID).First(&device).Error; err != nil || device.Token != "legacy-token" {
+		t.Errorf("Expected the push token to become a device, got %+v (%v)", device, err)
+	}
+	if db.Migrator().HasColumn("users", "push_token") {
+		t.Error("Expected the push_token column to be dropped")
+	}
+}
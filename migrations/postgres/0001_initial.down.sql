@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reminders;
+DROP TABLE IF EXISTS plants;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,32 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations. Existing
+-- databases already have it, hence IF NOT EXISTS. Whatever AutoMigrate added later
+-- is brought in by 0009.
+
+CREATE TABLE IF NOT EXISTS users (
+	id BIGSERIAL PRIMARY KEY,
+	email TEXT,
+	password TEXT,
+	name TEXT,
+	creation_date TIMESTAMPTZ,
+	push_token TEXT
+);
+
+CREATE TABLE IF NOT EXISTS plants (
+	id BIGSERIAL PRIMARY KEY,
+	name TEXT,
+	note TEXT,
+	tag_color TEXT,
+	user_id BIGINT,
+	plant_icon TEXT
+);
+
+CREATE TABLE IF NOT EXISTS reminders (
+	id BIGSERIAL PRIMARY KEY,
+	plant_id BIGINT,
+	"repeat" SMALLINT,
+	time_of_day TEXT,
+	next_trigger_time TIMESTAMPTZ,
+	user_id BIGINT,
+	day_of_week SMALLINT,
+	day_of_month SMALLINT
+);
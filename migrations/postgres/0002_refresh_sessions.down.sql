@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS refresh_sessions;
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN token_version BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE refresh_sessions (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	family_id TEXT NOT NULL,
+	jti TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	replaced_by TEXT NOT NULL DEFAULT '',
+	revoked_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX idx_refresh_sessions_jti ON refresh_sessions (jti);
+CREATE INDEX idx_refresh_sessions_user_id ON refresh_sessions (user_id);
+CREATE INDEX idx_refresh_sessions_family_id ON refresh_sessions (family_id);
@@ -0,0 +1,10 @@
+CREATE TABLE password_resets (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	token_hash TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX idx_password_resets_token_hash ON password_resets (token_hash);
+CREATE INDEX idx_password_resets_user_id ON password_resets (user_id);
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT false;
+-- Accounts from before verification existed are trusted.
+UPDATE users SET email_verified = true;
+
+CREATE TABLE email_verifications (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	token_hash TEXT NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE UNIQUE INDEX idx_email_verifications_token_hash ON email_verifications (token_hash);
+CREATE INDEX idx_email_verifications_user_id ON email_verifications (user_id);
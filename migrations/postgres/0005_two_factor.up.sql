@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	code_hash TEXT NOT NULL,
+	used_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
@@ -0,0 +1,10 @@
+CREATE TABLE user_identities (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT NOT NULL,
+	provider TEXT NOT NULL,
+	subject TEXT NOT NULL,
+	email TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+CREATE UNIQUE INDEX idx_user_identities_provider_subject ON user_identities (provider, subject);
@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS notification_outbox;
+DROP TABLE IF EXISTS care_events;
+DROP TABLE IF EXISTS devices;
+
+DROP INDEX IF EXISTS idx_reminders_task_type;
+ALTER TABLE reminders DROP COLUMN task_type;
+ALTER TABLE reminders DROP COLUMN task_label;
+ALTER TABLE reminders DROP COLUMN snoozed_until;
+ALTER TABLE reminders DROP COLUMN days_of_week;
+ALTER TABLE reminders DROP COLUMN "interval";
+ALTER TABLE reminders DROP COLUMN cron_expression;
+ALTER TABLE reminders DROP COLUMN last_completed_at;
+ALTER TABLE reminders DROP COLUMN created_at;
+
+ALTER TABLE users DROP COLUMN time_zone;
+ALTER TABLE users ADD COLUMN push_token TEXT;
@@ -0,0 +1,64 @@
+-- What AutoMigrate added to the baseline before versioned migrations replaced it.
+-- Databases it migrated have some or all of it already, hence IF NOT EXISTS.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS time_zone TEXT DEFAULT 'UTC';
+
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS task_type TEXT DEFAULT 'water';
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS task_label TEXT;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS snoozed_until TIMESTAMPTZ;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS days_of_week TEXT;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS "interval" SMALLINT;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS cron_expression TEXT;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS last_completed_at TIMESTAMPTZ;
+ALTER TABLE reminders ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS idx_reminders_task_type ON reminders (task_type);
+
+CREATE TABLE IF NOT EXISTS devices (
+	id BIGSERIAL PRIMARY KEY,
+	user_id BIGINT,
+	token TEXT,
+	platform TEXT,
+	app_version TEXT,
+	last_seen_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ,
+	delivery_failures BIGINT DEFAULT 0
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_devices_token ON devices (token);
+CREATE INDEX IF NOT EXISTS idx_devices_user_id ON devices (user_id);
+
+CREATE TABLE IF NOT EXISTS care_events (
+	id BIGSERIAL PRIMARY KEY,
+	reminder_id BIGINT,
+	plant_id BIGINT,
+	user_id BIGINT,
+	action TEXT,
+	note TEXT,
+	"timestamp" TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_care_events_reminder_id ON care_events (reminder_id);
+CREATE INDEX IF NOT EXISTS idx_care_events_plant_id ON care_events (plant_id);
+CREATE INDEX IF NOT EXISTS idx_care_events_timestamp ON care_events ("timestamp");
+
+CREATE TABLE IF NOT EXISTS notification_outbox (
+	id BIGSERIAL PRIMARY KEY,
+	reminder_id BIGINT,
+	user_id BIGINT,
+	device_id BIGINT,
+	title TEXT,
+	body TEXT,
+	status TEXT,
+	attempts BIGINT,
+	next_attempt_at TIMESTAMPTZ,
+	last_error TEXT,
+	created_at TIMESTAMPTZ,
+	sent_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_reminder_id ON notification_outbox (reminder_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_due ON notification_outbox (status, next_attempt_at);
+
+-- Move the legacy single push token of each user into the devices table.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS push_token TEXT;
+INSERT INTO devices (user_id, token, platform, app_version, last_seen_at, created_at, delivery_failures)
+SELECT id, push_token, '', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, 0 FROM users WHERE push_token <> ''
+ON CONFLICT (token) DO NOTHING;
+ALTER TABLE users DROP COLUMN push_token;
@@ -0,0 +1,32 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations. Existing
+-- databases already have it, hence IF NOT EXISTS. Whatever AutoMigrate added later
+-- is brought in by 0009.
+
+CREATE TABLE IF NOT EXISTS users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	email TEXT,
+	password TEXT,
+	name TEXT,
+	creation_date DATETIME,
+	push_token TEXT
+);
+
+CREATE TABLE IF NOT EXISTS plants (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT,
+	note TEXT,
+	tag_color TEXT,
+	user_id INTEGER,
+	plant_icon TEXT
+);
+
+CREATE TABLE IF NOT EXISTS reminders (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	plant_id INTEGER,
+	"repeat" SMALLINT,
+	time_of_day TEXT,
+	next_trigger_time DATETIME,
+	user_id INTEGER,
+	day_of_week INTEGER,
+	day_of_month INTEGER
+);
@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN token_version INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE refresh_sessions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	family_id TEXT NOT NULL,
+	jti TEXT NOT NULL,
+	expires_at DATETIME NOT NULL,
+	replaced_by TEXT NOT NULL DEFAULT '',
+	revoked_at DATETIME,
+	created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX idx_refresh_sessions_jti ON refresh_sessions (jti);
+CREATE INDEX idx_refresh_sessions_user_id ON refresh_sessions (user_id);
+CREATE INDEX idx_refresh_sessions_family_id ON refresh_sessions (family_id);
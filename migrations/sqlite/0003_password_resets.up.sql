@@ -0,0 +1,10 @@
+CREATE TABLE password_resets (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	token_hash TEXT NOT NULL,
+	expires_at DATETIME NOT NULL,
+	used_at DATETIME,
+	created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX idx_password_resets_token_hash ON password_resets (token_hash);
+CREATE INDEX idx_password_resets_user_id ON password_resets (user_id);
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT 0;
+-- Accounts from before verification existed are trusted.
+UPDATE users SET email_verified = 1;
+
+CREATE TABLE email_verifications (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	token_hash TEXT NOT NULL,
+	expires_at DATETIME NOT NULL,
+	created_at DATETIME NOT NULL
+);
+CREATE UNIQUE INDEX idx_email_verifications_token_hash ON email_verifications (token_hash);
+CREATE INDEX idx_email_verifications_user_id ON email_verifications (user_id);
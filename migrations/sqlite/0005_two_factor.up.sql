@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN totp_secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN totp_enabled BOOLEAN NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN totp_last_step INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE recovery_codes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	code_hash TEXT NOT NULL,
+	used_at DATETIME,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
@@ -0,0 +1,10 @@
+ALTER TABLE users ADD COLUMN failed_logins INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN locked_until DATETIME;
+
+CREATE TABLE rate_limit_buckets (
+	bucket_key TEXT PRIMARY KEY,
+	tokens REAL NOT NULL,
+	updated_at DATETIME NOT NULL,
+	full_at DATETIME NOT NULL
+);
+CREATE INDEX idx_rate_limit_buckets_full_at ON rate_limit_buckets (full_at);
@@ -0,0 +1,12 @@
+CREATE TABLE api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL,
+	scopes TEXT NOT NULL,
+	last_used_at DATETIME,
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys (key_hash);
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// APIKey lets scripts and integrations use the API on behalf of a user, limited to
+// its scopes. Only the SHA-256 hash of the key is stored; Prefix is the start of
+// the key, to tell keys apart.
+type APIKey struct {
+	ID      int64 `gorm:"primaryKey"`
+	UserID  int64 `gorm:"index"`
+	Name    string
+	Prefix  string
+	KeyHash string `gorm:"uniqueIndex"`
+	// Scopes are the granted constants.APIKeyScope values, separated by spaces.
+	Scopes     string
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+}
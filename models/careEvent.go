@@ -0,0 +1,17 @@
+package models
+
+import (
+	"plant-reminder/constants"
+	"time"
+)
+
+type CareEvent struct {
+	ID         int64 `gorm:"primaryKey"`
+	ReminderID int64 `gorm:"index"`
+	PlantID    int64 `gorm:"index"`
+	UserID     int64
+	Action     constants.CareAction
+	Note       string
+	Timestamp  time.Time `gorm:"index"`
+	Plant      *Plant    `gorm:"foreignKey:PlantID;constraint:OnDelete:CASCADE"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+type Device struct {
+	ID               int64  `gorm:"primaryKey"`
+	UserID           int64  `gorm:"index"`
+	Token            string `gorm:"uniqueIndex"`
+	Platform         string
+	AppVersion       string
+	LastSeenAt       time.Time
+	CreatedAt        time.Time
+	DeliveryFailures int   `gorm:"default:0"`
+	User             *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
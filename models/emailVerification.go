@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// EmailVerification is a token mailed to a user to confirm their email address.
+// Only the SHA-256 hash of the token is stored; verifying deletes it.
+type EmailVerification struct {
+	ID        int64  `gorm:"primaryKey"`
+	UserID    int64  `gorm:"index"`
+	TokenHash string `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
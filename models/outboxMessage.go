@@ -0,0 +1,26 @@
+package models
+
+import (
+	"plant-reminder/constants"
+	"time"
+)
+
+// OutboxMessage is a push notification waiting to be delivered to one device.
+type OutboxMessage struct {
+	ID            int64 `gorm:"primaryKey"`
+	ReminderID    int64 `gorm:"index"`
+	UserID        int64
+	DeviceID      int64
+	Title         string
+	Body          string
+	Status        constants.OutboxStatus `gorm:"index:idx_outbox_due,priority:1"`
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index:idx_outbox_due,priority:2"`
+	LastError     string
+	CreatedAt     time.Time
+	SentAt        *time.Time
+}
+
+func (OutboxMessage) TableName() string {
+	return "notification_outbox"
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// PasswordReset is a single-use token that lets a user set a new password without
+// the current one. Only the SHA-256 hash of the token is stored.
+type PasswordReset struct {
+	ID        int64  `gorm:"primaryKey"`
+	UserID    int64  `gorm:"index"`
+	TokenHash string `gorm:"uniqueIndex"`
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RateLimitBucket is a token bucket shared by all instances. Buckets that are full
+// again since FullAt are deleted.
+type RateLimitBucket struct {
+	BucketKey string `gorm:"primaryKey"`
+	Tokens    float64
+	UpdatedAt time.Time `gorm:"autoUpdateTime:false"`
+	FullAt    time.Time `gorm:"index"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// RecoveryCode lets a user with two-factor authentication log in without their
+// authenticator, once. Only the SHA-256 hash of the code is stored.
+type RecoveryCode struct {
+	ID        int64 `gorm:"primaryKey"`
+	UserID    int64 `gorm:"index"`
+	CodeHash  string
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RefreshSession is one refresh token of a login. Refreshing replaces the session
+// with a new one of the same family; the family is what a logout revokes.
+type RefreshSession struct {
+	ID         int64  `gorm:"primaryKey"`
+	UserID     int64  `gorm:"index"`
+	FamilyID   string `gorm:"index"`
+	JTI        string `gorm:"column:jti;uniqueIndex"`
+	ExpiresAt  time.Time
+	ReplacedBy string
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
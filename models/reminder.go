@@ -8,11 +8,20 @@ import (
 type Reminder struct {
 	ID              int64 `gorm:"primaryKey"`
 	PlantID         int64
+	TaskType        constants.TaskType `gorm:"default:water;index"`
+	TaskLabel       string
 	Repeat          constants.RepeatType `gorm:"type:smallint"`
 	TimeOfDay       string
 	NextTriggerTime time.Time
+	SnoozedUntil    *time.Time
 	UserID          int64
+	User            *User  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 	Plant           *Plant `gorm:"foreignKey:PlantID;constraint:OnDelete:CASCADE"`
 	DayOfWeek       *int16
+	DaysOfWeek      []int16 `gorm:"serializer:json;type:text"`
 	DayOfMonth      *int16
+	Interval        *int16
+	CronExpression  string
+	LastCompletedAt *time.Time
+	CreatedAt       time.Time
 }
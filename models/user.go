@@ -10,6 +10,21 @@ type User struct {
 	Password     string `validate:"required,min=6"`
 	Name         string `validate:"omitempty,min=2,max=100"`
 	CreationDate time.Time
-	PushToken    string
-	Plants       []Plant `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	TimeZone     string `gorm:"default:UTC"`
+	// EmailVerified is set once the user confirmed their email address.
+	EmailVerified bool
+	// TOTPSecret is set on enrollment; TOTPEnabled once the user confirmed it with a
+	// code. TOTPLastStep is the time step of the last accepted code, so a code can't
+	// be used twice.
+	TOTPSecret   string `gorm:"column:totp_secret"`
+	TOTPEnabled  bool   `gorm:"column:totp_enabled"`
+	TOTPLastStep int64  `gorm:"column:totp_last_step"`
+	// FailedLogins counts failed passwords and second factors since the last
+	// successful login. Too many lock the account until LockedUntil.
+	FailedLogins int
+	LockedUntil  *time.Time
+	// TokenVersion is embedded in access tokens. Incrementing it revokes all of them.
+	TokenVersion int      `gorm:"default:0"`
+	Plants       []Plant  `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Devices      []Device `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
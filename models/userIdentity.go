@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to their account at an OpenID Connect provider. Subject
+// is the provider's ID of the account; Email is the address it had when linked.
+type UserIdentity struct {
+	ID        int64  `gorm:"primaryKey"`
+	UserID    int64  `gorm:"index"`
+	Provider  string `gorm:"uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string `gorm:"uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string
+	CreatedAt time.Time
+}
@@ -0,0 +1,133 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jsonWebKey is a key of the provider's JWKS. Only the fields of signature keys are
+// read.
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	Alg     string `json:"alg"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+// verificationKey is a parsed JWKS key with the algorithm tokens signed with it use.
+type verificationKey struct {
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// fetchKeys downloads the JWKS and returns its signature keys by kid. Keys of
+// unknown types are skipped, providers may publish keys we never need.
+func fetchKeys(client *http.Client, url string) (map[string]verificationKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := getJSON(client, url, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]verificationKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.parse()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) parse() (verificationKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() {
+			return verificationKey{}, fmt.Errorf("invalid RSA exponent")
+		}
+		method := jwt.GetSigningMethod(k.Alg)
+		if _, ok := method.(*jwt.SigningMethodRSA); !ok {
+			// The alg member is optional; RS256 is what OIDC requires.
+			method = jwt.SigningMethodRS256
+		}
+		return verificationKey{method: method, public: &rsa.PublicKey{N: n, E: int(e.Int64())}}, nil
+	case "EC":
+		var curve elliptic.Curve
+		var method jwt.SigningMethod
+		switch k.Curve {
+		case "P-256":
+			curve, method = elliptic.P256(), jwt.SigningMethodES256
+		case "P-384":
+			curve, method = elliptic.P384(), jwt.SigningMethodES384
+		case "P-521":
+			curve, method = elliptic.P521(), jwt.SigningMethodES512
+		default:
+			return verificationKey{}, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return verificationKey{}, fmt.Errorf("invalid EC key")
+		}
+		return verificationKey{method: method, public: &ecdsa.PublicKey{Curve: curve, X: x, Y: y}}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || k.Curve != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return verificationKey{}, fmt.Errorf("unsupported OKP key")
+		}
+		return verificationKey{method: jwt.SigningMethodEdDSA, public: ed25519.PublicKey(x)}, nil
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported key type %q", k.KeyType)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+func getJSON(client *http.Client, url string, v any) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,146 @@
+// Package oidctest provides a local OpenID Connect issuer for tests.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "test-key"
+
+// Issuer serves a discovery document, a JWKS and a token endpoint that redeems the
+// codes handed out by Authorize. Close it when done.
+type Issuer struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+
+	key *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+type grant struct {
+	redirectURI  string
+	codeVerifier string
+	claims       jwt.MapClaims
+}
+
+// NewIssuer starts an issuer for one client. Its issuer URL is Issuer.URL.
+func NewIssuer(clientID, clientSecret string) *Issuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	issuer := &Issuer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("GET /jwks", issuer.jwks)
+	mux.HandleFunc("POST /token", issuer.token)
+	issuer.Server = httptest.NewServer(mux)
+	return issuer
+}
+
+// Authorize returns a single-use code for an ID token with claims, on top of iss,
+// aud, iat and exp. redirectURI must be sent with the code. codeVerifier is the PKCE
+// verifier the code must be redeemed with, or empty.
+func (i *Issuer) Authorize(redirectURI, codeVerifier string, claims jwt.MapClaims) string {
+	code := rand.Text()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.codes[code] = grant{redirectURI: redirectURI, codeVerifier: codeVerifier, claims: claims}
+	return code
+}
+
+// IDToken signs an ID token with claims, on top of iss, aud, iat and exp.
+func (i *Issuer) IDToken(claims jwt.MapClaims) string {
+	now := time.Now()
+	all := jwt.MapClaims{
+		"iss": i.URL,
+		"aud": i.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(time.Hour).Unix(),
+	}
+	for name, value := range claims {
+		all[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, all)
+	token.Header["kid"] = keyID
+	signed, err := token.SignedString(i.key)
+	if err != nil {
+		panic(err)
+	}
+	return signed
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 i.URL,
+		"authorization_endpoint": i.URL + "/authorize",
+		"token_endpoint":         i.URL + "/token",
+		"jwks_uri":               i.URL + "/jwks",
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	public := i.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}},
+	})
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.PostFormValue("grant_type") != "authorization_code" ||
+		r.PostFormValue("client_id") != i.ClientID ||
+		r.PostFormValue("client_secret") != i.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	i.mu.Lock()
+	code := r.PostFormValue("code")
+	grant, ok := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+
+	if !ok || grant.redirectURI != r.PostFormValue("redirect_uri") || grant.codeVerifier != r.PostFormValue("code_verifier") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     i.IDToken(grant.claims),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
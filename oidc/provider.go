@@ -0,0 +1,224 @@
+// Package oidc signs users in with OpenID Connect providers such as Google or Apple.
+// Apps run the authorization code flow and hand the code to the server, which
+// exchanges it for an ID token and verifies that against the provider's keys.
+package oidc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// keysRefreshInterval is how often the JWKS may be fetched again for a token
+	// with an unknown kid, which is how key rotations are noticed.
+	keysRefreshInterval = time.Minute
+	// clockSkew is how far the provider's clock may be off.
+	clockSkew     = time.Minute
+	clientTimeout = 10 * time.Second
+)
+
+var (
+	ErrExchangeFailed = errors.New("authorization code exchange failed")
+	ErrInvalidIDToken = errors.New("invalid ID token")
+)
+
+// Config identifies the provider and our client registered with it.
+type Config struct {
+	Name         string `json:"name" yaml:"name"`
+	Issuer       string `json:"issuer" yaml:"issuer"`
+	ClientID     string `json:"clientId" yaml:"clientId"`
+	ClientSecret string `json:"clientSecret" yaml:"clientSecret"`
+}
+
+// Identity is the verified user of an ID token.
+type Identity struct {
+	// Subject is the provider's stable ID of the user. Unlike the email it never
+	// changes.
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider talks to one OpenID Connect issuer. Its discovery document and keys are
+// fetched on first use and cached.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu            sync.Mutex
+	tokenEndpoint string
+	jwksURI       string
+	keys          map[string]verificationKey
+	keysFetchedAt time.Time
+}
+
+// NewProvider returns a provider using client for its requests, or a client with a
+// timeout if client is nil.
+func NewProvider(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = &http.Client{Timeout: clientTimeout}
+	}
+	return &Provider{config: config, client: client}
+}
+
+func (p *Provider) Name() string {
+	return p.config.Name
+}
+
+// Exchange redeems an authorization code and returns the verified identity of its
+// ID token. redirectURI and codeVerifier must be the ones the app used to get the
+// code; codeVerifier is empty without PKCE. nonce must match the ID token's.
+func (p *Provider) Exchange(code, redirectURI, codeVerifier, nonce string) (*Identity, error) {
+	if err := p.discover(); err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", redirectURI)
+	form.Set("client_id", p.config.ClientID)
+	if p.config.ClientSecret != "" {
+		form.Set("client_secret", p.config.ClientSecret)
+	}
+	if codeVerifier != "" {
+		form.Set("code_verifier", codeVerifier)
+	}
+
+	resp, err := p.client.PostForm(p.tokenEndpoint, form)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrExchangeFailed, err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrExchangeFailed, resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s %s", ErrExchangeFailed, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return nil, fmt.Errorf("%w: no ID token in the response", ErrExchangeFailed)
+	}
+
+	return p.Verify(body.IDToken, nonce)
+}
+
+// idTokenClaims are the claims of an ID token we read.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+	// EmailVerified is a boolean, but some providers such as Apple send a string.
+	EmailVerified   any    `json:"email_verified"`
+	Name            string `json:"name"`
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+}
+
+// Verify checks an ID token's signature, issuer, audience, lifetime and nonce. An
+// empty nonce is rejected, so a token can't be replayed into a login that skipped it.
+func (p *Provider) Verify(rawIDToken, nonce string) (*Identity, error) {
+	if err := p.discover(); err != nil {
+		return nil, err
+	}
+
+	var claims idTokenClaims
+	_, err := jwt.ParseWithClaims(rawIDToken, &claims, p.keyFunc,
+		jwt.WithIssuer(p.config.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID {
+		return nil, fmt.Errorf("%w: issued to %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidIDToken)
+	}
+
+	verified := claims.EmailVerified == true || claims.EmailVerified == "true"
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         strings.TrimSpace(claims.Email),
+		EmailVerified: verified,
+		Name:          claims.Name,
+	}, nil
+}
+
+// keyFunc finds the key by kid, fetching the JWKS again if the kid is unknown. The
+// algorithm must be the key's.
+func (p *Provider) keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.keys[kid]
+	if !ok && time.Since(p.keysFetchedAt) >= keysRefreshInterval {
+		keys, err := fetchKeys(p.client, p.jwksURI)
+		if err != nil {
+			return nil, err
+		}
+		p.keys = keys
+		p.keysFetchedAt = time.Now()
+		key, ok = p.keys[kid]
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if token.Method != key.method {
+		return nil, fmt.Errorf("key %q is not for %s", kid, token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+// discover fetches the discovery document once. A failed attempt is retried with
+// the next login.
+func (p *Provider) discover() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tokenEndpoint != "" {
+		return nil
+	}
+
+	var document struct {
+		Issuer        string `json:"issuer"`
+		TokenEndpoint string `json:"token_endpoint"`
+		JWKSURI       string `json:"jwks_uri"`
+	}
+	url := strings.TrimSuffix(p.config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(p.client, url, &document); err != nil {
+		return fmt.Errorf("failed to discover %s: %w", p.config.Name, err)
+	}
+	if document.Issuer != p.config.Issuer {
+		return fmt.Errorf("failed to discover %s: issuer is %q", p.config.Name, document.Issuer)
+	}
+	if document.TokenEndpoint == "" || document.JWKSURI == "" {
+		return fmt.Errorf("failed to discover %s: incomplete discovery document", p.config.Name)
+	}
+
+	p.tokenEndpoint = document.TokenEndpoint
+	p.jwksURI = document.JWKSURI
+	return nil
+}
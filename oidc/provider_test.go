@@ -0,0 +1,92 @@
+package oidc
+
+import (
+	"errors"
+	"plant-reminder/oidc/oidctest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const redirectURI = "com.example.plantie:/oauth"
+
+func newProvider(t *testing.T) (*Provider, *oidctest.Issuer) {
+	t.Helper()
+	issuer := oidctest.NewIssuer("plantie", "secret")
+	t.Cleanup(issuer.Close)
+	provider := NewProvider(Config{
+		Name:         "stub",
+		Issuer:       issuer.URL,
+		ClientID:     "plantie",
+		ClientSecret: "secret",
+	}, nil)
+	return provider, issuer
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	provider, issuer := newProvider(t)
+	code := issuer.Authorize(redirectURI, "verifier", jwt.MapClaims{
+		"sub":            "user-1",
+		"email":          "user@example.com",
+		"email_verified": "true",
+		"name":           "User",
+		"nonce":          "n-1",
+	})
+
+	identity, err := provider.Exchange(code, redirectURI, "verifier", "n-1")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if identity.Subject != "user-1" || identity.Email != "user@example.com" || !identity.EmailVerified || identity.Name != "User" {
+		t.Errorf("Unexpected identity: %+v", identity)
+	}
+
+	if _, err := provider.Exchange(code, redirectURI, "verifier", "n-1"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("Expected a used code to fail, got %v", err)
+	}
+	code = issuer.Authorize(redirectURI, "verifier", jwt.MapClaims{"sub": "user-1", "nonce": "n-1"})
+	if _, err := provider.Exchange(code, redirectURI, "other", "n-1"); !errors.Is(err, ErrExchangeFailed) {
+		t.Errorf("Expected a wrong code verifier to fail, got %v", err)
+	}
+	code = issuer.Authorize(redirectURI, "verifier", jwt.MapClaims{"sub": "user-1", "nonce": "n-1"})
+	if _, err := provider.Exchange(code, redirectURI, "verifier", "n-2"); !errors.Is(err, ErrInvalidIDToken) {
+		t.Errorf("Expected a mismatched nonce to fail, got %v", err)
+	}
+}
+
+func TestProvider_Verify_RejectsInvalidTokens(t *testing.T) {
+	provider, issuer := newProvider(t)
+	other := oidctest.NewIssuer("plantie", "secret")
+	defer other.Close()
+
+	tests := map[string]struct {
+		token string
+		nonce string
+	}{
+		"expired":       {token: issuer.IDToken(jwt.MapClaims{"sub": "u", "exp": time.Now().Add(-time.Hour).Unix()})},
+		"wrong issuer":  {token: issuer.IDToken(jwt.MapClaims{"sub": "u", "iss": "https://evil.example.com"})},
+		"wrong aud":     {token: issuer.IDToken(jwt.MapClaims{"sub": "u", "aud": "someone-else"})},
+		"wrong azp":     {token: issuer.IDToken(jwt.MapClaims{"sub": "u", "aud": []string{"plantie", "x"}, "azp": "x"})},
+		"wrong nonce":   {token: issuer.IDToken(jwt.MapClaims{"sub": "u", "nonce": "a"}), nonce: "b"},
+		"missing nonce": {token: issuer.IDToken(jwt.MapClaims{"sub": "u"}), nonce: "b"},
+		"no nonce":      {token: issuer.IDToken(jwt.MapClaims{"sub": "u"})},
+		"no subject":    {token: issuer.IDToken(jwt.MapClaims{})},
+		"foreign key":   {token: other.IDToken(jwt.MapClaims{"sub": "u", "iss": issuer.URL})},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := provider.Verify(test.token, test.nonce); !errors.Is(err, ErrInvalidIDToken) {
+				t.Errorf("Expected ErrInvalidIDToken, got %v", err)
+			}
+		})
+	}
+
+	identity, err := provider.Verify(issuer.IDToken(jwt.MapClaims{"sub": "u", "email": "u@example.com", "nonce": "n-1"}), "n-1")
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if identity.EmailVerified {
+		t.Error("Expected the email to be unverified without email_verified")
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"plant-reminder/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DBStore keeps buckets in the rate_limit_buckets table, so all instances share
+// them. Every Take is a short transaction on the bucket's row.
+type DBStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+func (s *DBStore) Take(key string, burst int, interval time.Duration, now time.Time) (time.Duration, error) {
+	s.sweep(now)
+
+	var wait time.Duration
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var row models.RateLimitBucket
+		err := tx.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate}).
+			Where("bucket_key = ?", key).
+			Take(&row).Error
+		b := bucket{tokens: float64(burst), updatedAt: now}
+		if err == nil {
+			b = bucket{tokens: row.Tokens, updatedAt: row.UpdatedAt}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		b.refill(burst, interval, now)
+		wait = b.take(interval)
+
+		// Two instances creating the same bucket at once both get their token.
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&models.RateLimitBucket{
+			BucketKey: key,
+			Tokens:    b.tokens,
+			UpdatedAt: b.updatedAt,
+			FullAt:    b.fullAt(burst, interval),
+		}).Error
+	})
+	return wait, err
+}
+
+// sweep deletes the buckets that are full again, at most once per sweepInterval
+// per instance.
+func (s *DBStore) sweep(now time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = now
+	s.mu.Unlock()
+
+	if err := s.db.Where("full_at < ?", now).Delete(&models.RateLimitBucket{}).Error; err != nil {
+		fmt.Printf("failed to sweep rate limit buckets: %v\n", err)
+	}
+}
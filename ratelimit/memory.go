@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often stores drop buckets that are full again.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Every instance has its own.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	burst    int
+	interval time.Duration
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *MemoryStore) Take(key string, burst int, interval time.Duration, now time.Time) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(burst), updatedAt: now}, burst: burst, interval: interval}
+		s.buckets[key] = b
+	}
+	b.refill(burst, interval, now)
+	return b.take(interval), nil
+}
+
+// sweep drops the buckets that are full, which behave like missing ones.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !b.fullAt(b.burst, b.interval).After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
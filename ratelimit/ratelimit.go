@@ -0,0 +1,65 @@
+// Package ratelimit implements token bucket rate limits. Buckets live in a Store:
+// MemoryStore for a single instance, DBStore to share them between replicas.
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Store keeps token buckets by key.
+type Store interface {
+	// Take removes a token from the bucket of key, which holds up to burst tokens
+	// and gains one every interval. It returns how long to wait for the next token
+	// if the bucket is empty, zero if the token was taken.
+	Take(key string, burst int, interval time.Duration, now time.Time) (time.Duration, error)
+}
+
+// Limiter applies one limit to many keys, e.g. per client IP or per account.
+type Limiter struct {
+	store    Store
+	name     string
+	burst    int
+	interval time.Duration
+}
+
+// NewLimiter returns a limiter allowing bursts of burst requests per key, refilled
+// at one request per interval. name separates its buckets from those of other
+// limiters in the same store.
+func NewLimiter(store Store, name string, burst int, interval time.Duration) *Limiter {
+	return &Limiter{store: store, name: name, burst: burst, interval: interval}
+}
+
+// Allow takes a token for key. If there is none, it returns how long the caller
+// should wait before retrying.
+func (l *Limiter) Allow(key string) (time.Duration, error) {
+	return l.store.Take(l.name+":"+key, l.burst, l.interval, time.Now())
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// refill adds the tokens gained since the bucket was last updated.
+func (b *bucket) refill(burst int, interval time.Duration, now time.Time) {
+	elapsed := now.Sub(b.updatedAt)
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(burst), b.tokens+float64(elapsed)/float64(interval))
+		b.updatedAt = now
+	}
+}
+
+// take removes a token, or returns how long until there is one.
+func (b *bucket) take(interval time.Duration) time.Duration {
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) * float64(interval))
+}
+
+// fullAt returns when the bucket is refilled, from then on it can be forgotten.
+func (b *bucket) fullAt(burst int, interval time.Duration) time.Time {
+	return b.updatedAt.Add(time.Duration((float64(burst) - b.tokens) * float64(interval)))
+}
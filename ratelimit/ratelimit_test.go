@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"plant-reminder/config"
+	"plant-reminder/migrations"
+	"plant-reminder/models"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, store Store) {
+	t.Helper()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := range 3 {
+		wait, err := store.Take("a", 3, time.Second, now)
+		if err != nil {
+			t.Fatalf("Take failed: %v", err)
+		}
+		if wait != 0 {
+			t.Fatalf("Expected token %d of the burst, got a wait of %s", i+1, wait)
+		}
+	}
+
+	wait, err := store.Take("a", 3, time.Second, now)
+	if err != nil {
+		t.Fatalf("Take failed: %v", err)
+	}
+	if wait != time.Second {
+		t.Errorf("Expected to wait 1s on an empty bucket, got %s", wait)
+	}
+
+	if wait, _ := store.Take("b", 3, time.Second, now); wait != 0 {
+		t.Errorf("Expected other keys to have their own bucket, got a wait of %s", wait)
+	}
+
+	if wait, _ := store.Take("a", 3, time.Second, now.Add(500*time.Millisecond)); wait != 500*time.Millisecond {
+		t.Errorf("Expected half a token after 500ms, got a wait of %s", wait)
+	}
+	if wait, _ := store.Take("a", 3, time.Second, now.Add(time.Second)); wait != 0 {
+		t.Errorf("Expected a token after 1s, got a wait of %s", wait)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestDBStore(t *testing.T) {
+	db, err := config.OpenDb(config.DatabaseConfig{Driver: config.DriverSQLite, URL: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	store := NewDBStore(db)
+	testStore(t, store)
+
+	// Buckets that are full again are swept.
+	store.lastSweep = time.Time{}
+	store.sweep(time.Date(2024, 1, 1, 13, 0, 0, 0, time.UTC))
+	var count int64
+	db.Model(&models.RateLimitBucket{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected full buckets to be swept, %d left", count)
+	}
+}
+
+func TestLimiter_SeparatesNames(t *testing.T) {
+	store := NewMemoryStore()
+	login := NewLimiter(store, "login", 1, time.Minute)
+	mail := NewLimiter(store, "mail", 1, time.Minute)
+
+	if wait, _ := login.Allow("user"); wait != 0 {
+		t.Fatalf("Expected the first login to pass, got a wait of %s", wait)
+	}
+	if wait, _ := login.Allow("user"); wait == 0 {
+		t.Error("Expected the second login to wait")
+	}
+	if wait, _ := mail.Allow("user"); wait != 0 {
+		t.Errorf("Expected the mail limiter to have its own bucket, got a wait of %s", wait)
+	}
+}
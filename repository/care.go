@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"plant-reminder/constants"
+	"plant-reminder/models"
+
+	"gorm.io/gorm"
+)
+
+// careEventColumns is the number of columns of care_events, an upper bound for the
+// bind parameters per inserted row.
+const careEventColumns = 7
+
+// CareEventRepository stores the care log: completed, skipped and missed reminder
+// occurrences.
+type CareEventRepository interface {
+	Create(event *models.CareEvent) error
+	// CreateMany stores the events with as few INSERTs as the bind parameter limit allows.
+	CreateMany(events []models.CareEvent) error
+	// FindByPlant lists the care log of the user's plant, newest first.
+	FindByPlant(plantID int64, userID int64) ([]models.CareEvent, error)
+	// FindOverdue lists the user's missed occurrences of existing reminders that
+	// weren't completed or skipped afterwards, newest first, with Plant loaded.
+	FindOverdue(userID int64) ([]models.CareEvent, error)
+}
+
+type careEventRepository struct {
+	db *gorm.DB
+}
+
+func NewCareEventRepository(db *gorm.DB) CareEventRepository {
+	return &careEventRepository{db: db}
+}
+
+func (r *careEventRepository) Create(event *models.CareEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *careEventRepository) CreateMany(events []models.CareEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(events, insertBatchSize(careEventColumns)).Error
+}
+
+func (r *careEventRepository) FindByPlant(plantID int64, userID int64) ([]models.CareEvent, error) {
+	var events []models.CareEvent
+	err := r.db.
+		Where("plant_id = ? AND user_id = ?", plantID, userID).
+		Order("timestamp DESC").
+		Find(&events).Error
+	return events, err
+}
+
+func (r *careEventRepository) FindOverdue(userID int64) ([]models.CareEvent, error) {
+	var events []models.CareEvent
+	err := r.db.
+		Preload("Plant").
+		Where("user_id = ? AND action = ?", userID, constants.CareActionMissed).
+		Where("reminder_id IN (SELECT id FROM reminders WHERE user_id = ?)", userID).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM care_events resolved
+			WHERE resolved.reminder_id = care_events.reminder_id
+			AND resolved.action IN ?
+			AND resolved.timestamp >= care_events.timestamp)`,
+			[]constants.CareAction{constants.CareActionDone, constants.CareActionSkipped}).
+		Order("timestamp DESC").
+		Find(&events).Error
+	return events, err
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// outboxColumns is the number of columns of notification_outbox, an upper bound for
+// the bind parameters per inserted row.
+const outboxColumns = 12
+
+// OutboxRepository stores the push notifications waiting to be delivered.
+type OutboxRepository interface {
+	// Enqueue stores the messages with as few INSERTs as the bind parameter limit allows.
+	Enqueue(messages []models.OutboxMessage) error
+	// Claim locks up to limit pending messages that are due at now, skipping rows
+	// locked by other workers, counts an attempt for each and hides them from other
+	// workers until now+lease.
+	Claim(now time.Time, limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	// Finish sets the final status of message, and its sent time if it was sent.
+	Finish(message *models.OutboxMessage, status constants.OutboxStatus, lastError string, now time.Time) error
+	// ScheduleRetry records a failed attempt and when to try again.
+	ScheduleRetry(message *models.OutboxMessage, nextAttemptAt time.Time, lastError string) error
+	// FindStuck lists up to limit messages that were not delivered on the first
+	// attempt, newest first: dead ones, pending ones that are being retried, or both
+	// if status is empty.
+	FindStuck(status constants.OutboxStatus, limit int) ([]models.OutboxMessage, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Enqueue(messages []models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.CreateInBatches(messages, insertBatchSize(outboxColumns)).Error
+}
+
+func (r *outboxRepository) Claim(now time.Time, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.
+			Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate, Options: clause.LockingOptionsSkipLocked}).
+			Where("status = ? AND next_attempt_at <= ?", constants.OutboxPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(messages))
+		for i := range messages {
+			ids[i] = messages[i].ID
+		}
+		return tx.Model(&models.OutboxMessage{}).
+			Where("id IN ?", ids).
+			Updates(map[string]interface{}{
+				"attempts":        gorm.Expr("attempts + 1"),
+				"next_attempt_at": now.Add(lease),
+			}).Error
+	})
+	return messages, err
+}
+
+func (r *outboxRepository) Finish(message *models.OutboxMessage, status constants.OutboxStatus, lastError string, now time.Time) error {
+	updates := map[string]interface{}{"status": status, "last_error": lastError}
+	if status == constants.OutboxSent {
+		updates["sent_at"] = now
+	}
+	return r.db.Model(message).Updates(updates).Error
+}
+
+func (r *outboxRepository) ScheduleRetry(message *models.OutboxMessage, nextAttemptAt time.Time, lastError string) error {
+	return r.db.Model(message).Updates(map[string]interface{}{
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}).Error
+}
+
+func (r *outboxRepository) FindStuck(status constants.OutboxStatus, limit int) ([]models.OutboxMessage, error) {
+	query := r.db.Order("created_at DESC").Limit(limit)
+	switch status {
+	case constants.OutboxPending:
+		query = query.Where("status = ? AND attempts > 0", status)
+	case constants.OutboxDead:
+		query = query.Where("status = ?", status)
+	default:
+		query = query.Where("status = ? OR (status = ? AND attempts > 0)", constants.OutboxDead, constants.OutboxPending)
+	}
+
+	var messages []models.OutboxMessage
+	err := query.Find(&messages).Error
+	return messages, err
+}
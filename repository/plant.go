@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"plant-reminder/models"
+
+	"gorm.io/gorm"
+)
+
+type PlantRepository interface {
+	Create(plant *models.Plant) error
+	// FindByID returns the plant if it belongs to the user.
+	FindByID(plantID int64, userID int64) (*models.Plant, error)
+	FindByUser(userID int64) ([]models.Plant, error)
+	// Update writes the non-zero fields of plant.
+	Update(plant *models.Plant) error
+	Delete(plant *models.Plant) error
+}
+
+type plantRepository struct {
+	db *gorm.DB
+}
+
+func NewPlantRepository(db *gorm.DB) PlantRepository {
+	return &plantRepository{db: db}
+}
+
+func (r *plantRepository) Create(plant *models.Plant) error {
+	return r.db.Create(plant).Error
+}
+
+func (r *plantRepository) FindByID(plantID int64, userID int64) (*models.Plant, error) {
+	var plant models.Plant
+	if err := r.db.Where("id = ? AND user_id = ?", plantID, userID).First(&plant).Error; err != nil {
+		return nil, err
+	}
+	return &plant, nil
+}
+
+func (r *plantRepository) FindByUser(userID int64) ([]models.Plant, error) {
+	var plants []models.Plant
+	err := r.db.Where("user_id = ?", userID).Find(&plants).Error
+	return plants, err
+}
+
+func (r *plantRepository) Update(plant *models.Plant) error {
+	return r.db.Model(&models.Plant{ID: plant.ID}).Updates(plant).Error
+}
+
+func (r *plantRepository) Delete(plant *models.Plant) error {
+	return r.db.Delete(plant).Error
+}
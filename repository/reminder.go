@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClaimFunc handles a batch of claimed due reminders inside the claiming transaction
+// and returns the reminders to save. Writes that have to commit together with the
+// claim go through the repositories of tx.
+type ClaimFunc func(tx *Tx, due []models.Reminder) ([]models.Reminder, error)
+
+type ReminderRepository interface {
+	Create(reminder *models.Reminder) error
+	FindByID(reminderID int64) (*models.Reminder, error)
+	FindByIDs(reminderIDs []int64) ([]models.Reminder, error)
+	// FindForUser returns the user's reminder with User loaded.
+	FindForUser(reminderID int64, userID int64) (*models.Reminder, error)
+	// FindForPlant returns the reminder if it belongs to the user's plant, with User loaded.
+	FindForPlant(reminderID int64, plantID int64, userID int64) (*models.Reminder, error)
+	// FindByPlant lists the plant's reminders with User loaded. An empty task type matches all.
+	FindByPlant(plantID int64, userID int64, taskType constants.TaskType) ([]models.Reminder, error)
+	// FindByUser lists the user's reminders with Plant and User loaded. An empty task type matches all.
+	FindByUser(userID int64, taskType constants.TaskType) ([]models.Reminder, error)
+	// FindDue lists up to limit reminders of all users that are due at or before
+	// until, soonest first, with Plant and User loaded.
+	FindDue(until time.Time, limit int) ([]models.Reminder, error)
+	// FindPage lists up to limit reminders with id > afterID ordered by id, with User loaded.
+	FindPage(afterID int64, limit int) ([]models.Reminder, error)
+	// FindSameSlot lists the other reminders of the plant for the same task, time
+	// and repeat type as reminder, the candidates for a duplicate schedule.
+	FindSameSlot(reminder *models.Reminder) ([]models.Reminder, error)
+	// Save writes all fields of the reminders, but not their associations.
+	Save(reminders ...*models.Reminder) error
+	// UpdateColumns writes only the given columns of reminder.
+	UpdateColumns(reminder *models.Reminder, columns ...string) error
+	// Reschedule writes reminder's next trigger time unless the stored one is no
+	// longer previous, i.e. the reminder fired or was changed since it was read. It
+	// reports whether the row was updated.
+	Reschedule(reminder *models.Reminder, previous time.Time) (bool, error)
+	Delete(reminder *models.Reminder) error
+	// ClaimDue locks up to limit reminders with id > afterID that are due at now,
+	// skipping rows locked by other replicas, loads their User and Plant and passes
+	// them to handle. The reminders it returns are saved before the transaction commits.
+	ClaimDue(now time.Time, afterID int64, limit int, handle ClaimFunc) error
+}
+
+type reminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository(db *gorm.DB) ReminderRepository {
+	return &reminderRepository{db: db}
+}
+
+func (r *reminderRepository) Create(reminder *models.Reminder) error {
+	return r.db.Omit(clause.Associations).Create(reminder).Error
+}
+
+func (r *reminderRepository) FindByID(reminderID int64) (*models.Reminder, error) {
+	var reminder models.Reminder
+	if err := r.db.Where("id = ?", reminderID).First(&reminder).Error; err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (r *reminderRepository) FindByIDs(reminderIDs []int64) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	if len(reminderIDs) == 0 {
+		return reminders, nil
+	}
+	err := r.db.Where("id IN ?", reminderIDs).Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *reminderRepository) FindForUser(reminderID int64, userID int64) (*models.Reminder, error) {
+	var reminder models.Reminder
+	err := r.db.Preload("User").Where("id = ? AND user_id = ?", reminderID, userID).First(&reminder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (r *reminderRepository) FindForPlant(reminderID int64, plantID int64, userID int64) (*models.Reminder, error) {
+	var reminder models.Reminder
+	err := r.db.
+		Preload("User").
+		Where("id = ? AND plant_id = ? AND user_id = ?", reminderID, plantID, userID).
+		First(&reminder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (r *reminderRepository) FindByPlant(plantID int64, userID int64, taskType constants.TaskType) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	query := r.db.Preload("User").Where("plant_id = ? AND user_id = ?", plantID, userID)
+	if taskType != "" {
+		query = query.Where("task_type = ?", taskType)
+	}
+	err := query.Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *reminderRepository) FindByUser(userID int64, taskType constants.TaskType) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	query := r.db.Preload("Plant").Preload("User").Where("user_id = ?", userID)
+	if taskType != "" {
+		query = query.Where("task_type = ?", taskType)
+	}
+	err := query.Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *reminderRepository) FindDue(until time.Time, limit int) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := r.db.
+		Preload("Plant").
+		Preload("User").
+		Where("next_trigger_time <= ? OR snoozed_until <= ?", until, until).
+		Order("next_trigger_time").
+		Limit(limit).
+		Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *reminderRepository) FindPage(afterID int64, limit int) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := r.db.Preload("User").Where("id > ?", afterID).Order("id").Limit(limit).Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *reminderRepository) FindSameSlot(reminder *models.Reminder) ([]models.Reminder, error) {
+	var reminders []models.Reminder
+	err := r.db.
+		Where("plant_id = ? AND task_type = ? AND time_of_day = ? AND repeat = ? AND id != ?",
+			reminder.PlantID, reminder.TaskType, reminder.TimeOfDay, reminder.Repeat, reminder.ID).
+		Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *reminderRepository) Save(reminders ...*models.Reminder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, reminder := range reminders {
+			if err := tx.Omit(clause.Associations).Save(reminder).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *reminderRepository) UpdateColumns(reminder *models.Reminder, columns ...string) error {
+	return r.db.Model(reminder).Select(columns).Updates(reminder).Error
+}
+
+func (r *reminderRepository) Reschedule(reminder *models.Reminder, previous time.Time) (bool, error) {
+	result := r.db.Model(reminder).
+		Where("next_trigger_time = ?", previous).
+		UpdateColumn("next_trigger_time", reminder.NextTriggerTime)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *reminderRepository) Delete(reminder *models.Reminder) error {
+	return r.db.Delete(reminder).Error
+}
+
+func (r *reminderRepository) ClaimDue(now time.Time, afterID int64, limit int, handle ClaimFunc) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// User and plant come from the same query. Only the reminder rows are
+		// locked, Postgres can't lock the nullable side of an outer join anyway.
+		// SQLite has no row locks and ignores the clause; its writers are serialized.
+		var due []models.Reminder
+		err := tx.
+			Clauses(clause.Locking{
+				Strength: clause.LockingStrengthUpdate,
+				Table:    clause.Table{Name: clause.CurrentTable},
+				Options:  clause.LockingOptionsSkipLocked,
+			}).
+			Joins("User").
+			Joins("Plant").
+			Where("reminders.id > ? AND (reminders.next_trigger_time <= ? OR reminders.snoozed_until <= ?)", afterID, now, now).
+			Order("reminders.id").
+			Limit(limit).
+			Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		updated, err := handle(newTx(tx), due)
+		if err != nil || len(updated) == 0 {
+			return err
+		}
+		return tx.Omit(clause.Associations).Save(&updated).Error
+	})
+}
@@ -0,0 +1,35 @@
+// Package repository keeps database access behind one interface per aggregate.
+// The implementations use GORM and work with every dialect config.InitDb supports,
+// Postgres and SQLite.
+package repository
+
+import "gorm.io/gorm"
+
+// ErrNotFound is returned when a looked up record doesn't exist.
+var ErrNotFound = gorm.ErrRecordNotFound
+
+// maxBindParameters is how many bind parameters a single statement may have: 32766
+// for SQLite, 65535 for Postgres.
+const maxBindParameters = 32766
+
+// insertBatchSize returns how many rows of a table with the given number of columns
+// fit into one multi-row INSERT.
+func insertBatchSize(columns int) int {
+	return maxBindParameters / columns
+}
+
+// Tx holds repositories bound to one transaction, for writes that have to commit
+// together.
+type Tx struct {
+	Users      UserRepository
+	CareEvents CareEventRepository
+	Outbox     OutboxRepository
+}
+
+func newTx(tx *gorm.DB) *Tx {
+	return &Tx{
+		Users:      NewUserRepository(tx),
+		CareEvents: NewCareEventRepository(tx),
+		Outbox:     NewOutboxRepository(tx),
+	}
+}
@@ -0,0 +1,481 @@
+package repository
+
+import (
+	"plant-reminder/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserRepository stores users together with the devices they receive pushes on,
+// their refresh sessions, the tokens mailed to them, their recovery codes, their
+// API keys and their identities at login providers.
+type UserRepository interface {
+	Create(user *models.User) error
+	FindByID(userID int64) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	// Update writes the non-zero fields of user.
+	Update(user *models.User) error
+	// Delete removes the user, their devices, refresh sessions, mailed tokens,
+	// recovery codes, API keys and provider identities.
+	Delete(user *models.User) error
+
+	// UpsertDevice registers a device, or refreshes it if its token is already
+	// known. A token seen under another account moves to device.UserID.
+	UpsertDevice(device *models.Device) error
+	// FindDevices returns the user's devices, most recently seen first.
+	FindDevices(userID int64) ([]models.Device, error)
+	FindDevice(deviceID int64, userID int64) (*models.Device, error)
+	DeleteDevice(device *models.Device) error
+	// FindActiveDevices returns the users' devices seen after seenAfter. With
+	// verifiedOnly, devices of users with an unverified email are left out.
+	FindActiveDevices(userIDs []int64, seenAfter time.Time, verifiedOnly bool) ([]models.Device, error)
+	// FindDevicesByID returns those of the devices that still exist.
+	FindDevicesByID(deviceIDs []int64) ([]models.Device, error)
+	// RecordDeliveryFailure increments the device's failed deliveries in a row.
+	RecordDeliveryFailure(device *models.Device) error
+	ResetDeliveryFailures(device *models.Device) error
+
+	CreateSession(session *models.RefreshSession) error
+	FindSession(jti string) (*models.RefreshSession, error)
+	// RotateSession marks session as replaced by next and stores next. It returns
+	// false without storing anything if session was replaced or revoked meanwhile.
+	RotateSession(session *models.RefreshSession, next *models.RefreshSession) (bool, error)
+	RevokeSessionFamily(familyID string) error
+	// RevokeTokens revokes all refresh sessions of the user and increments their
+	// token version, which invalidates their access tokens.
+	RevokeTokens(userID int64) error
+	// DeleteExpiredSessions removes the user's sessions that expired before now.
+	DeleteExpiredSessions(userID int64, now time.Time) error
+
+	// CreatePasswordReset stores reset and deletes the user's earlier resets, so
+	// only the latest reset email works.
+	CreatePasswordReset(reset *models.PasswordReset) error
+	// UsePasswordReset marks the unused reset with the token hash as used and
+	// returns it. It returns ErrNotFound if there is none or it expired before now.
+	UsePasswordReset(tokenHash string, now time.Time) (*models.PasswordReset, error)
+
+	// CreateEmailVerification stores verification and deletes the user's earlier
+	// ones, so only the latest verification email works.
+	CreateEmailVerification(verification *models.EmailVerification) error
+	// UseEmailVerification marks the owner of the verification with the token hash
+	// as verified and deletes their verifications. It returns ErrNotFound if there
+	// is none or it expired before now.
+	UseEmailVerification(tokenHash string, now time.Time) (*models.EmailVerification, error)
+
+	// SetTOTPSecret stores the secret of a two-factor enrollment that isn't
+	// confirmed yet.
+	SetTOTPSecret(userID int64, secret string) error
+	// EnableTOTP turns two-factor authentication on, records the time step of the
+	// confirming code and replaces the user's recovery codes.
+	EnableTOTP(userID int64, step int64, codes []models.RecoveryCode) error
+	// DisableTOTP turns two-factor authentication off and deletes the secret and the
+	// recovery codes.
+	DisableTOTP(userID int64) error
+	// UseTOTPStep records an accepted code's time step. It returns false if a code
+	// of this or a later step was accepted before.
+	UseTOTPStep(userID int64, step int64) (bool, error)
+	// UseRecoveryCode marks the user's unused recovery code with the hash as used.
+	// It returns false if there is none.
+	UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error)
+
+	// RecordFailedLogin increments the user's failed logins and returns the new count.
+	RecordFailedLogin(userID int64) (int, error)
+	LockUser(userID int64, until time.Time) error
+	// ResetFailedLogins clears the failed logins and the lock.
+	ResetFailedLogins(userID int64) error
+
+	CreateAPIKey(key *models.APIKey) error
+	CountAPIKeys(userID int64) (int64, error)
+	// FindAPIKeys returns the user's API keys, newest first.
+	FindAPIKeys(userID int64) ([]models.APIKey, error)
+	FindAPIKeyByHash(keyHash string) (*models.APIKey, error)
+	// DeleteAPIKey deletes the API key if it belongs to the user. It returns
+	// ErrNotFound otherwise.
+	DeleteAPIKey(keyID int64, userID int64) error
+	TouchAPIKey(keyID int64, now time.Time) error
+
+	FindIdentity(provider string, subject string) (*models.UserIdentity, error)
+	// LinkIdentity links a provider's identity to an existing user. With a
+	// passwordHash it also marks their email as verified and replaces their
+	// password, revoking their tokens and clearing failed logins like a new
+	// password does.
+	LinkIdentity(identity *models.UserIdentity, passwordHash string) error
+	// CreateWithIdentity creates a user who signed up through a login provider.
+	CreateWithIdentity(user *models.User, identity *models.UserIdentity) error
+}
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r *userRepository) FindByID(userID int64) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) Update(user *models.User) error {
+	return r.db.Model(&models.User{ID: user.ID}).Updates(user).Error
+}
+
+func (r *userRepository) Delete(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RefreshSession{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.PasswordReset{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		return tx.Select("Devices").Delete(user).Error
+	})
+}
+
+func (r *userRepository) UpsertDevice(device *models.Device) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "app_version", "last_seen_at", "delivery_failures"}),
+	}).Create(device).Error
+}
+
+func (r *userRepository) FindDevices(userID int64) ([]models.Device, error) {
+	var devices []models.Device
+	err := r.db.Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error
+	return devices, err
+}
+
+func (r *userRepository) FindDevice(deviceID int64, userID int64) (*models.Device, error) {
+	var device models.Device
+	if err := r.db.Where("id = ? AND user_id = ?", deviceID, userID).First(&device).Error; err != nil {
+		return nil, err
+	}
+	return &device, nil
+}
+
+func (r *userRepository) DeleteDevice(device *models.Device) error {
+	return r.db.Delete(device).Error
+}
+
+func (r *userRepository) FindActiveDevices(userIDs []int64, seenAfter time.Time, verifiedOnly bool) ([]models.Device, error) {
+	var devices []models.Device
+	query := r.db.Where("devices.user_id IN ? AND devices.last_seen_at > ?", userIDs, seenAfter)
+	if verifiedOnly {
+		query = query.Joins("JOIN users ON users.id = devices.user_id").Where("users.email_verified = ?", true)
+	}
+	err := query.Find(&devices).Error
+	return devices, err
+}
+
+func (r *userRepository) FindDevicesByID(deviceIDs []int64) ([]models.Device, error) {
+	var devices []models.Device
+	err := r.db.Where("id IN ?", deviceIDs).Find(&devices).Error
+	return devices, err
+}
+
+func (r *userRepository) RecordDeliveryFailure(device *models.Device) error {
+	return r.db.Model(device).Update("delivery_failures", gorm.Expr("delivery_failures + 1")).Error
+}
+
+func (r *userRepository) ResetDeliveryFailures(device *models.Device) error {
+	return r.db.Model(device).Update("delivery_failures", 0).Error
+}
+
+func (r *userRepository) CreateSession(session *models.RefreshSession) error {
+	return r.db.Create(session).Error
+}
+
+func (r *userRepository) FindSession(jti string) (*models.RefreshSession, error) {
+	var session models.RefreshSession
+	if err := r.db.Where("jti = ?", jti).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *userRepository) RotateSession(session *models.RefreshSession, next *models.RefreshSession) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// The conditions make concurrent refreshes with the same token race for
+		// the row; only one of them wins.
+		result := tx.Model(&models.RefreshSession{}).
+			Where("id = ? AND replaced_by = '' AND revoked_at IS NULL", session.ID).
+			Update("replaced_by", next.JTI)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		rotated = true
+		return tx.Create(next).Error
+	})
+	return rotated, err
+}
+
+func (r *userRepository) RevokeSessionFamily(familyID string) error {
+	return r.db.Model(&models.RefreshSession{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *userRepository) RevokeTokens(userID int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RefreshSession{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{ID: userID}).
+			Update("token_version", gorm.Expr("token_version + 1")).Error
+	})
+}
+
+func (r *userRepository) DeleteExpiredSessions(userID int64, now time.Time) error {
+	return r.db.Where("user_id = ? AND expires_at < ?", userID, now).Delete(&models.RefreshSession{}).Error
+}
+
+func (r *userRepository) CreatePasswordReset(reset *models.PasswordReset) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", reset.UserID).Delete(&models.PasswordReset{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(reset).Error
+	})
+}
+
+func (r *userRepository) UsePasswordReset(tokenHash string, now time.Time) (*models.PasswordReset, error) {
+	var reset models.PasswordReset
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			First(&reset).Error
+		if err != nil {
+			return err
+		}
+		// Two requests with the same token race for the row; only one of them wins.
+		result := tx.Model(&models.PasswordReset{}).
+			Where("id = ? AND used_at IS NULL", reset.ID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+func (r *userRepository) CreateEmailVerification(verification *models.EmailVerification) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", verification.UserID).Delete(&models.EmailVerification{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(verification).Error
+	})
+}
+
+func (r *userRepository) UseEmailVerification(tokenHash string, now time.Time) (*models.EmailVerification, error) {
+	var verification models.EmailVerification
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("token_hash = ? AND expires_at > ?", tokenHash, now).First(&verification).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.User{ID: verification.UserID}).Update("email_verified", true).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", verification.UserID).Delete(&models.EmailVerification{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+func (r *userRepository) SetTOTPSecret(userID int64, secret string) error {
+	return r.db.Model(&models.User{ID: userID}).Updates(map[string]any{
+		"totp_secret":    secret,
+		"totp_enabled":   false,
+		"totp_last_step": 0,
+	}).Error
+}
+
+func (r *userRepository) EnableTOTP(userID int64, step int64, codes []models.RecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{ID: userID}).Updates(map[string]any{
+			"totp_enabled":   true,
+			"totp_last_step": step,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *userRepository) DisableTOTP(userID int64) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{ID: userID}).Updates(map[string]any{
+			"totp_secret":    "",
+			"totp_enabled":   false,
+			"totp_last_step": 0,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+	})
+}
+
+func (r *userRepository) UseTOTPStep(userID int64, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) UseRecoveryCode(userID int64, codeHash string, now time.Time) (bool, error) {
+	result := r.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", now)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) RecordFailedLogin(userID int64) (int, error) {
+	var failures int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{ID: userID}).
+			Update("failed_logins", gorm.Expr("failed_logins + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).Select("failed_logins").Scan(&failures).Error
+	})
+	return failures, err
+}
+
+func (r *userRepository) LockUser(userID int64, until time.Time) error {
+	return r.db.Model(&models.User{ID: userID}).Update("locked_until", until).Error
+}
+
+func (r *userRepository) ResetFailedLogins(userID int64) error {
+	return r.db.Model(&models.User{ID: userID}).Updates(map[string]any{
+		"failed_logins": 0,
+		"locked_until":  nil,
+	}).Error
+}
+
+func (r *userRepository) CreateAPIKey(key *models.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *userRepository) CountAPIKeys(userID int64) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *userRepository) FindAPIKeys(userID int64) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *userRepository) FindAPIKeyByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *userRepository) DeleteAPIKey(keyID int64, userID int64) error {
+	result := r.db.Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) TouchAPIKey(keyID int64, now time.Time) error {
+	return r.db.Model(&models.APIKey{ID: keyID}).Update("last_used_at", now).Error
+}
+
+func (r *userRepository) FindIdentity(provider string, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *userRepository) LinkIdentity(identity *models.UserIdentity, passwordHash string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(identity).Error; err != nil {
+			return err
+		}
+		if passwordHash == "" {
+			return nil
+		}
+		err := tx.Model(&models.User{ID: identity.UserID}).Updates(map[string]any{
+			"email_verified": true,
+			"password":       passwordHash,
+			"failed_logins":  0,
+			"locked_until":   nil,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return NewUserRepository(tx).RevokeTokens(identity.UserID)
+	})
+}
+
+func (r *userRepository) CreateWithIdentity(user *models.User, identity *models.UserIdentity) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return tx.Create(identity).Error
+	})
+}
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"expvar"
+	"plant-reminder/constants"
 	"plant-reminder/container"
 	"plant-reminder/middleware"
 
@@ -13,29 +15,76 @@ func SetupRouter(engine *gin.Engine, app *container.Application) {
 	plantController := app.PlantController
 	userController := app.UserController
 	reminderController := app.ReminderController
+	careController := app.CareController
+	adminController := app.AdminController
+	jwksController := app.JWKSController
 
 	engine.GET("/ping", healthController.Ping)
+	engine.GET("/.well-known/jwks.json", jwksController.GetJWKS)
 
-	engine.POST("/login", userController.Login)
-	engine.POST("/signup", userController.SignUp)
-	engine.POST("/refresh", userController.RefreshToken)
+	accountLimit := middleware.RateLimit(app.AccountLimiter, middleware.JSONField("email"))
+	publicGroup := engine.Group("/", middleware.RateLimit(app.IPLimiter, middleware.ClientIP))
 
-	authGroup := engine.Group("/", middleware.VerifyAuth)
+	publicGroup.POST("/login", accountLimit, userController.Login)
+	publicGroup.POST("/login/2fa", userController.LoginTwoFactor)
+	publicGroup.POST("/login/oidc/:provider", userController.LoginWithProvider)
+	publicGroup.POST("/signup", userController.SignUp)
+	publicGroup.POST("/refresh", userController.RefreshToken)
+	publicGroup.POST("/password/forgot", middleware.RateLimit(app.MailLimiter, middleware.JSONField("email")), userController.ForgotPassword)
+	publicGroup.POST("/password/reset", userController.ResetPassword)
+	publicGroup.POST("/verify-email", userController.VerifyEmail)
 
-	authGroup.POST("/user/push_token", userController.SetPushToken)
-	authGroup.DELETE("/user", userController.DeleteUser)
-	authGroup.GET("/user/me", userController.GetMyProfile)
+	authGroup := engine.Group("/", middleware.VerifyAuth(app.UserService))
 
-	authGroup.POST("/plant", plantController.AddPlant)
-	authGroup.DELETE("/plant/:id", plantController.DeletePlant)
-	authGroup.PUT("/plant/:id", plantController.UpdatePlant)
-	authGroup.GET("/plant/:id", plantController.GetPlant)
-	authGroup.GET("/plants", plantController.GetPlants)
+	// Account management needs a login; API keys only get the groups their
+	// scopes allow.
+	accountGroup := authGroup.Group("/", middleware.RequireSession())
 
-	authGroup.POST("/plant/:id/reminder", reminderController.AddReminder)
-	authGroup.DELETE("/plant/:id/reminder/:reminderId", reminderController.DeleteReminder)
-	authGroup.PUT("/plant/:id/reminder", reminderController.UpdateReminder)
-	authGroup.GET("/plant/:id/reminders", reminderController.GetPlantReminders)
-	authGroup.GET("/plant/reminders", reminderController.GetAllReminders)
-	authGroup.POST("/reminders/test", reminderController.TestReminder)
+	accountGroup.POST("/logout", userController.Logout)
+	accountGroup.POST("/logout/all", userController.LogoutAll)
+	accountGroup.POST("/resend-verification", middleware.RateLimit(app.MailLimiter, middleware.UserID), userController.ResendVerification)
+
+	accountGroup.POST("/user/push_token", userController.SetPushToken)
+	accountGroup.GET("/user/devices", userController.GetDevices)
+	accountGroup.DELETE("/user/devices/:deviceId", userController.DeleteDevice)
+	accountGroup.DELETE("/user", userController.DeleteUser)
+	accountGroup.GET("/user/me", userController.GetMyProfile)
+	accountGroup.PUT("/user/me", userController.UpdateMyProfile)
+	accountGroup.PUT("/user/password", userController.ChangePassword)
+	accountGroup.POST("/user/2fa/enroll", userController.EnrollTwoFactor)
+	accountGroup.POST("/user/2fa/confirm", userController.ConfirmTwoFactor)
+	accountGroup.POST("/user/2fa/disable", userController.DisableTwoFactor)
+	accountGroup.POST("/user/api-keys", userController.CreateAPIKey)
+	accountGroup.GET("/user/api-keys", userController.GetAPIKeys)
+	accountGroup.DELETE("/user/api-keys/:keyId", userController.DeleteAPIKey)
+
+	plantsRead := authGroup.Group("/", middleware.RequireScope(constants.ScopePlantsRead))
+	plantsWrite := authGroup.Group("/", middleware.RequireScope(constants.ScopePlantsWrite))
+
+	plantsWrite.POST("/plant", plantController.AddPlant)
+	plantsWrite.DELETE("/plant/:id", plantController.DeletePlant)
+	plantsWrite.PUT("/plant/:id", plantController.UpdatePlant)
+	plantsRead.GET("/plant/:id", plantController.GetPlant)
+	plantsRead.GET("/plants", plantController.GetPlants)
+
+	remindersRead := authGroup.Group("/", middleware.RequireScope(constants.ScopeRemindersRead))
+	remindersWrite := authGroup.Group("/", middleware.RequireScope(constants.ScopeRemindersWrite))
+
+	remindersWrite.POST("/plant/:id/reminder", reminderController.AddReminder)
+	remindersWrite.DELETE("/plant/:id/reminder/:reminderId", reminderController.DeleteReminder)
+	remindersWrite.PUT("/plant/:id/reminder", reminderController.UpdateReminder)
+	remindersRead.GET("/plant/:id/reminders", reminderController.GetPlantReminders)
+	remindersRead.GET("/plant/reminders", reminderController.GetAllReminders)
+	remindersWrite.POST("/plant/:id/reminder/:reminderId/snooze", reminderController.SnoozeReminder)
+	remindersWrite.POST("/reminders/test", reminderController.TestReminder)
+
+	remindersWrite.POST("/plant/:id/reminder/:reminderId/complete", careController.CompleteReminder)
+	remindersWrite.POST("/plant/:id/reminder/:reminderId/skip", careController.SkipReminder)
+	remindersRead.GET("/plant/:id/history", careController.GetPlantHistory)
+	remindersRead.GET("/plant/overdue", careController.GetOverdueTasks)
+
+	adminGroup := engine.Group("/admin", middleware.VerifyAdmin(app.Config.AdminToken))
+
+	adminGroup.GET("/outbox", adminController.GetStuckNotifications)
+	adminGroup.GET("/metrics", gin.WrapH(expvar.Handler()))
 }
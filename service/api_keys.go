@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"plant-reminder/dto"
+	"plant-reminder/models"
+	"plant-reminder/repository"
+	"plant-reminder/utils"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	maxAPIKeys = 20
+	// apiKeyPrefixLength is how much of a key is kept to show in the key list.
+	apiKeyPrefixLength = len(utils.APIKeyPrefix) + 4
+	// apiKeyTouchInterval is how stale the last used time of a key may get, so not
+	// every request writes to the database.
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrTooManyAPIKeys = errors.New("too many API keys")
+	ErrInvalidAPIKey  = errors.New("invalid API key")
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// CreateAPIKey creates a key with the requested scopes. The key is only returned
+// here; afterwards only its hash is known.
+func (s *UserService) CreateAPIKey(userID int64, request *dto.APIKeyCreateRequest) (*dto.APIKeyCreatedResponse, error) {
+	count, err := s.users.CountAPIKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeys {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	scopes := slices.Clone(request.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	key := utils.NewAPIKey()
+	apiKey := &models.APIKey{
+		UserID:  userID,
+		Name:    request.Name,
+		Prefix:  key[:apiKeyPrefixLength],
+		KeyHash: utils.HashToken(key),
+		Scopes:  strings.Join(scopes, " "),
+	}
+	if err := s.users.CreateAPIKey(apiKey); err != nil {
+		return nil, err
+	}
+
+	return &dto.APIKeyCreatedResponse{
+		APIKeyResponse: *(&dto.APIKeyResponse{}).FromModel(apiKey),
+		Key:            key,
+	}, nil
+}
+
+func (s *UserService) GetAPIKeys(userID int64) ([]dto.APIKeyResponse, error) {
+	keys, err := s.users.FindAPIKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.FromAPIKeysModel(keys), nil
+}
+
+// DeleteAPIKey revokes the key.
+func (s *UserService) DeleteAPIKey(userID int64, keyID int64) error {
+	err := s.users.DeleteAPIKey(keyID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// AuthenticateAPIKey returns the owner and the scopes of an API key and records
+// that it was used.
+func (s *UserService) AuthenticateAPIKey(key string) (int64, []string, error) {
+	apiKey, err := s.users.FindAPIKeyByHash(utils.HashToken(key))
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		// A failed update only leaves the last used time behind.
+		if err := s.users.TouchAPIKey(apiKey.ID, now); err != nil {
+			fmt.Printf("failed to record use of API key %d: %v\n", apiKey.ID, err)
+		}
+	}
+	return apiKey.UserID, strings.Fields(apiKey.Scopes), nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"errors"
+	"plant-reminder/constants"
+	"plant-reminder/dto"
+	"plant-reminder/models"
+	"plant-reminder/repository"
+	"time"
+)
+
+var (
+	ErrPlantNotFound    = errors.New("plant doesn't exist")
+	ErrReminderNotFound = errors.New("reminder doesn't exist")
+	ErrFutureTimestamp  = errors.New("timestamp can't be in the future")
+)
+
+type CareService struct {
+	plantService    *PlantService
+	reminderService *ReminderService
+	events          repository.CareEventRepository
+	reminders       repository.ReminderRepository
+}
+
+type CareServiceInterface interface {
+	CompleteReminder(request *dto.CareEventRequest, plantID int64, reminderID int64, userID int64) (*dto.CareEventResponse, error)
+	SkipReminder(request *dto.CareEventRequest, plantID int64, reminderID int64, userID int64) (*dto.CareEventResponse, error)
+	GetPlantHistory(plantID int64, userID int64) (*dto.PlantHistoryResponse, error)
+	GetOverdueTasks(userID int64) ([]dto.OverdueTaskResponse, error)
+}
+
+func NewCareService(ps *PlantService, rs *ReminderService, events repository.CareEventRepository, reminders repository.ReminderRepository) *CareService {
+	return &CareService{
+		plantService:    ps,
+		reminderService: rs,
+		events:          events,
+		reminders:       reminders,
+	}
+}
+
+func (s *CareService) CompleteReminder(request *dto.CareEventRequest, plantID int64, reminderID int64, userID int64) (*dto.CareEventResponse, error) {
+	return s.recordEvent(request, plantID, reminderID, userID, constants.CareActionDone)
+}
+
+func (s *CareService) SkipReminder(request *dto.CareEventRequest, plantID int64, reminderID int64, userID int64) (*dto.CareEventResponse, error) {
+	return s.recordEvent(request, plantID, reminderID, userID, constants.CareActionSkipped)
+}
+
+// GetPlantHistory returns the plant's care log, newest first, together with the
+// current streak of completed tasks since the last skipped or missed one.
+func (s *CareService) GetPlantHistory(plantID int64, userID int64) (*dto.PlantHistoryResponse, error) {
+	_, err := s.plantService.GetPlant(plantID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrPlantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.events.FindByPlant(plantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &dto.PlantHistoryResponse{
+		PlantID: plantID,
+		Events:  dto.FromCareEventsModel(events),
+	}
+
+	streakBroken := false
+	for _, event := range events {
+		if event.Action == constants.CareActionDone {
+			if history.LastCompleted == nil {
+				timestamp := event.Timestamp
+				history.LastCompleted = &timestamp
+			}
+			if !streakBroken {
+				history.Streak++
+			}
+		} else {
+			streakBroken = true
+		}
+	}
+
+	return history, nil
+}
+
+// GetOverdueTasks returns the user's missed reminder occurrences, newest first. An
+// occurrence stops being overdue once its reminder is completed or skipped afterwards.
+func (s *CareService) GetOverdueTasks(userID int64) ([]dto.OverdueTaskResponse, error) {
+	events, err := s.events.FindOverdue(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderIDs := make([]int64, 0, len(events))
+	for _, event := range events {
+		reminderIDs = append(reminderIDs, event.ReminderID)
+	}
+	reminders, err := s.reminders.FindByIDs(reminderIDs)
+	if err != nil {
+		return nil, err
+	}
+	remindersByID := make(map[int64]*models.Reminder, len(reminders))
+	for i := range reminders {
+		remindersByID[reminders[i].ID] = &reminders[i]
+	}
+
+	tasks := make([]dto.OverdueTaskResponse, 0, len(events))
+	for i := range events {
+		reminder, ok := remindersByID[events[i].ReminderID]
+		if !ok {
+			continue
+		}
+		tasks = append(tasks, *(&dto.OverdueTaskResponse{}).FromModel(&events[i], reminder))
+	}
+	return tasks, nil
+}
+
+func (s *CareService) recordEvent(request *dto.CareEventRequest, plantID int64, reminderID int64, userID int64, action constants.CareAction) (*dto.CareEventResponse, error) {
+	if request.Timestamp != nil && request.Timestamp.After(time.Now()) {
+		return nil, ErrFutureTimestamp
+	}
+
+	reminder, err := s.reminders.FindForPlant(reminderID, plantID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrReminderNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := request.ToModel(userID, plantID, reminderID, action)
+	if err := s.events.Create(event); err != nil {
+		return nil, err
+	}
+
+	if action == constants.CareActionDone {
+		if err := s.reminderService.markCompleted(reminder, event.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+
+	return (&dto.CareEventResponse{}).FromModel(event), nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"expvar"
+	"plant-reminder/constants"
+	"time"
+)
+
+const (
+	defaultDispatchWorkers   = 4
+	defaultDispatchBatchSize = 500
+	defaultCheckInterval     = time.Minute
+	defaultOutboxInterval    = 10 * time.Second
+)
+
+// DispatchConfig tunes how due reminders are claimed and their notifications delivered.
+type DispatchConfig struct {
+	CatchUpPolicy constants.CatchUpPolicy
+	// Workers is the number of concurrent push deliveries. Keep it below the size of
+	// the database connection pool, as every delivery writes its result.
+	Workers int
+	// BatchSize is the number of reminders or outbox messages claimed per transaction.
+	BatchSize int
+	// CheckInterval is how often due reminders are claimed.
+	CheckInterval time.Duration
+	// OutboxInterval is how often the outbox worker looks for messages to send.
+	OutboxInterval time.Duration
+	// VerifiedUsersOnly skips the devices of users who haven't verified their email.
+	VerifiedUsersOnly bool
+}
+
+// withDefaults fills in unset fields.
+func (c DispatchConfig) withDefaults() DispatchConfig {
+	if c.CatchUpPolicy == "" {
+		c.CatchUpPolicy = constants.CatchUpOnce
+	}
+	if c.Workers <= 0 {
+		c.Workers = defaultDispatchWorkers
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultDispatchBatchSize
+	}
+	if c.CheckInterval <= 0 {
+		c.CheckInterval = defaultCheckInterval
+	}
+	if c.OutboxInterval <= 0 {
+		c.OutboxInterval = defaultOutboxInterval
+	}
+	return c
+}
+
+// Dispatch metrics, published through expvar and served on /admin/metrics.
+var (
+	reminderMetrics = expvar.NewMap("reminder_dispatch")
+	outboxMetrics   = expvar.NewMap("notification_outbox")
+)
+
+// recordTick adds one scheduler run that handled batchSize items to the metrics.
+func recordTick(metrics *expvar.Map, batchSize int, duration time.Duration) {
+	metrics.Add("ticks", 1)
+	metrics.Add("items_total", int64(batchSize))
+	metrics.Add("tick_duration_ms_total", duration.Milliseconds())
+	setMetric(metrics, "last_batch_size", int64(batchSize))
+	setMetric(metrics, "last_tick_duration_ms", duration.Milliseconds())
+}
+
+func setMetric(metrics *expvar.Map, key string, value int64) {
+	v := new(expvar.Int)
+	v.Set(value)
+	metrics.Set(key, v)
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"plant-reminder/dto"
+	"plant-reminder/models"
+	"plant-reminder/oidc"
+	"plant-reminder/repository"
+	"plant-reminder/utils"
+	"strings"
+	"time"
+)
+
+// OIDCProvider redeems authorization codes of an OpenID Connect provider.
+type OIDCProvider interface {
+	Exchange(code, redirectURI, codeVerifier, nonce string) (*oidc.Identity, error)
+}
+
+var (
+	ErrUnknownProvider          = errors.New("unknown login provider")
+	ErrProviderLoginFailed      = errors.New("login with the provider failed")
+	ErrProviderEmailNotVerified = errors.New("the login provider has not verified the email address")
+)
+
+// LoginWithProvider logs in with an authorization code of a login provider and
+// returns the same response as VerifyUser. The provider's account is linked to the
+// user with its verified email address, or to a new user if there is none.
+func (s *UserService) LoginWithProvider(provider string, request *dto.OIDCLoginRequest) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+	oidcProvider, ok := s.config.OIDCProviders[provider]
+	if !ok {
+		return nil, nil, ErrUnknownProvider
+	}
+
+	identity, err := oidcProvider.Exchange(request.Code, request.RedirectURI, request.CodeVerifier, request.Nonce)
+	if errors.Is(err, oidc.ErrExchangeFailed) || errors.Is(err, oidc.ErrInvalidIDToken) {
+		fmt.Printf("login with %s failed: %v\n", provider, err)
+		return nil, nil, ErrProviderLoginFailed
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.findOrLinkUser(provider, identity, request)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.completeLogin(user)
+}
+
+func (s *UserService) findOrLinkUser(provider string, identity *oidc.Identity, request *dto.OIDCLoginRequest) (*models.User, error) {
+	linked, err := s.users.FindIdentity(provider, identity.Subject)
+	if err == nil {
+		return s.users.FindByID(linked.UserID)
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrProviderEmailNotVerified
+	}
+	link := &models.UserIdentity{Provider: provider, Subject: identity.Subject, Email: identity.Email}
+
+	user, err := s.users.FindByEmail(identity.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.createProviderUser(link, identity, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	link.UserID = user.ID
+	if user.EmailVerified {
+		if err := s.users.LinkIdentity(link, ""); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	// Whoever signed up with this address never proved they own it, the provider's
+	// user did. Lock the other one out by replacing the password.
+	hashedPassword, err := utils.HashPassword(utils.NewSecretToken())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.users.LinkIdentity(link, hashedPassword); err != nil {
+		return nil, err
+	}
+	fmt.Printf("verified user %d and replaced their password on linking %s\n", user.ID, provider)
+	return s.users.FindByID(user.ID)
+}
+
+// createProviderUser signs up a provider's user. The account gets a random
+// password, which the user can replace through a password reset.
+func (s *UserService) createProviderUser(link *models.UserIdentity, identity *oidc.Identity, request *dto.OIDCLoginRequest) (*models.User, error) {
+	hashedPassword, err := utils.HashPassword(utils.NewSecretToken())
+	if err != nil {
+		return nil, err
+	}
+	name := identity.Name
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+	timeZone := request.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	user := &models.User{
+		Email:         identity.Email,
+		Password:      hashedPassword,
+		Name:          name,
+		TimeZone:      timeZone,
+		CreationDate:  time.Now(),
+		EmailVerified: true,
+	}
+	if err := s.users.CreateWithIdentity(user, link); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
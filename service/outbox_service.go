@@ -0,0 +1,246 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"plant-reminder/constants"
+	"plant-reminder/dto"
+	"plant-reminder/models"
+	"plant-reminder/repository"
+	"plant-reminder/utils"
+	"sync"
+	"time"
+)
+
+// FCM treats tokens of devices that have been inactive this long as expired.
+const staleDeviceAge = 270 * 24 * time.Hour
+
+const (
+	maxOutboxAttempts = 8
+	// outboxLease is how long a claimed message is hidden from other workers. If the
+	// worker dies mid-delivery, the message is retried after the lease runs out.
+	outboxLease       = 2 * time.Minute
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = time.Hour
+)
+
+// OutboxService delivers push notifications through the notification_outbox table,
+// retrying transient failures with exponential backoff.
+type OutboxService struct {
+	messages repository.OutboxRepository
+	users    repository.UserRepository
+	notifier utils.Notifier
+	config   DispatchConfig
+}
+
+type OutboxServiceInterface interface {
+	GetStuckMessages(status constants.OutboxStatus) ([]dto.OutboxMessageResponse, error)
+}
+
+func NewOutboxService(messages repository.OutboxRepository, users repository.UserRepository, notifier utils.Notifier, config DispatchConfig) *OutboxService {
+	return &OutboxService{
+		messages: messages,
+		users:    users,
+		notifier: notifier,
+		config:   config.withDefaults(),
+	}
+}
+
+// outboxMessages builds one pending message per device.
+func outboxMessages(reminderID int64, devices []models.Device, notification utils.Notification, now time.Time) []models.OutboxMessage {
+	messages := make([]models.OutboxMessage, len(devices))
+	for i := range devices {
+		messages[i] = models.OutboxMessage{
+			ReminderID:    reminderID,
+			UserID:        devices[i].UserID,
+			DeviceID:      devices[i].ID,
+			Title:         notification.Title,
+			Body:          notification.Body,
+			Status:        constants.OutboxPending,
+			NextAttemptAt: now,
+		}
+	}
+	return messages
+}
+
+// ProcessOutbox delivers the pending messages that are due, batch by batch, through
+// a pool of config.Workers goroutines.
+func (s *OutboxService) ProcessOutbox() error {
+	start := time.Now()
+	processed := 0
+	defer func() { recordTick(outboxMetrics, processed, time.Since(start)) }()
+
+	for {
+		messages, err := s.messages.Claim(start, s.config.BatchSize, outboxLease)
+		if err != nil {
+			return err
+		}
+		processed += len(messages)
+		if len(messages) == 0 {
+			return nil
+		}
+
+		if err := s.deliverBatch(messages); err != nil {
+			return err
+		}
+		if len(messages) < s.config.BatchSize {
+			return nil
+		}
+	}
+}
+
+// deliverBatch loads the devices of all messages with one query and sends the
+// messages through the worker pool.
+func (s *OutboxService) deliverBatch(messages []models.OutboxMessage) error {
+	deviceIDs := make([]int64, len(messages))
+	for i := range messages {
+		deviceIDs[i] = messages[i].DeviceID
+	}
+	devices, err := s.users.FindDevicesByID(deviceIDs)
+	if err != nil {
+		return err
+	}
+	devicesByID := make(map[int64]*models.Device, len(devices))
+	for i := range devices {
+		devicesByID[devices[i].ID] = &devices[i]
+	}
+
+	jobs := make(chan *models.OutboxMessage)
+	var wg sync.WaitGroup
+	for range min(s.config.Workers, len(messages)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for message := range jobs {
+				s.deliver(message, devicesByID[message.DeviceID])
+			}
+		}()
+	}
+	for i := range messages {
+		jobs <- &messages[i]
+	}
+	close(jobs)
+	wg.Wait()
+	return nil
+}
+
+// deliver sends one claimed message. device is nil if it was unregistered meanwhile.
+func (s *OutboxService) deliver(message *models.OutboxMessage, device *models.Device) {
+	message.Attempts++
+	if device == nil {
+		s.finish(message, constants.OutboxDead, "device no longer registered")
+		return
+	}
+
+	result := s.notifier.SendMessage(device.Token, utils.Notification{Title: message.Title, Body: message.Body})
+	s.recordDelivery(device, result)
+
+	switch {
+	case result.Delivered():
+		s.finish(message, constants.OutboxSent, "")
+		outboxMetrics.Add("sent", 1)
+	case !result.IsRetryable() || message.Attempts >= maxOutboxAttempts:
+		s.finish(message, constants.OutboxDead, result.Error().Error())
+		outboxMetrics.Add("dead", 1)
+	default:
+		err := s.messages.ScheduleRetry(message, time.Now().Add(outboxBackoff(message.Attempts)), result.Error().Error())
+		if err != nil {
+			fmt.Println("failed to schedule notification retry:", err)
+		}
+		outboxMetrics.Add("retried", 1)
+	}
+}
+
+func (s *OutboxService) finish(message *models.OutboxMessage, status constants.OutboxStatus, lastError string) {
+	if err := s.messages.Finish(message, status, lastError, time.Now()); err != nil {
+		fmt.Println("failed to update outbox message:", err)
+	}
+}
+
+// outboxBackoff returns the delay before the next attempt: 30s, 1m, 2m, ... capped at an hour.
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
+
+// GetStuckMessages lists messages that were not delivered on the first attempt: dead
+// ones and pending ones that are being retried. A status narrows the list down.
+func (s *OutboxService) GetStuckMessages(status constants.OutboxStatus) ([]dto.OutboxMessageResponse, error) {
+	switch status {
+	case "", constants.OutboxPending, constants.OutboxDead:
+	default:
+		return nil, errors.New("status must be pending or dead")
+	}
+
+	messages, err := s.messages.FindStuck(status, s.config.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	return dto.FromOutboxMessagesModel(messages), nil
+}
+
+// sendNow delivers a notification to all active devices of the user right away,
+// bypassing the outbox. It fails only if no device received the notification.
+func (s *OutboxService) sendNow(userID int64, notification utils.Notification) error {
+	devices, err := s.activeDevices(s.users, userID)
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		return errors.New("user doesn't have any registered devices")
+	}
+
+	delivered := false
+	var lastErr error
+	for i := range devices {
+		result := s.notifier.SendMessage(devices[i].Token, notification)
+		s.recordDelivery(&devices[i], result)
+		if result.Delivered() {
+			delivered = true
+		} else {
+			lastErr = result.Error()
+		}
+	}
+	if !delivered {
+		return lastErr
+	}
+	return nil
+}
+
+// activeDevices returns the users' devices that have checked in recently enough
+// for FCM to still consider their tokens valid. With config.VerifiedUsersOnly,
+// devices of users with an unverified email are left out. users may be bound to
+// the caller's transaction.
+func (s *OutboxService) activeDevices(users repository.UserRepository, userIDs ...int64) ([]models.Device, error) {
+	return users.FindActiveDevices(userIDs, time.Now().Add(-staleDeviceAge), s.config.VerifiedUsersOnly)
+}
+
+func (s *OutboxService) recordDelivery(device *models.Device, result utils.DeliveryResult) {
+	if result.Delivered() {
+		if device.DeliveryFailures != 0 {
+			if err := s.users.ResetDeliveryFailures(device); err != nil {
+				fmt.Println("failed to record delivery result:", err)
+			}
+		}
+		return
+	}
+
+	fmt.Printf("push delivery to device %d of user %d failed (%s): %v\n", device.ID, device.UserID, result.Status, result.Err)
+
+	if result.IsPermanentFailure() {
+		if err := s.users.DeleteDevice(device); err != nil {
+			fmt.Println("failed to remove dead device:", err)
+		}
+		return
+	}
+	if !result.IsRetryable() {
+		// The message was at fault, not the device.
+		return
+	}
+	if err := s.users.RecordDeliveryFailure(device); err != nil {
+		fmt.Println("failed to record delivery result:", err)
+	}
+}
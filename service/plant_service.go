@@ -4,12 +4,11 @@ import (
 	"errors"
 	"plant-reminder/dto"
 	"plant-reminder/models"
-
-	"gorm.io/gorm"
+	"plant-reminder/repository"
 )
 
 type PlantService struct {
-	db *gorm.DB
+	plants repository.PlantRepository
 }
 
 type PlantServiceInterface interface {
@@ -20,9 +19,9 @@ type PlantServiceInterface interface {
 	DeletePlant(userID int64, plantID int64) error
 }
 
-func NewPlantService(db *gorm.DB) *PlantService {
+func NewPlantService(plants repository.PlantRepository) *PlantService {
 	return &PlantService{
-		db: db,
+		plants: plants,
 	}
 }
 
@@ -33,9 +32,8 @@ func (s *PlantService) CreatePlant(plantRequest *dto.PlantCreateRequest, userID
 		return nil, err
 	}
 
-	result := s.db.Create(plant)
-	if result.Error != nil {
-		return nil, result.Error
+	if err := s.plants.Create(plant); err != nil {
+		return nil, err
 	}
 
 	response := (&dto.PlantResponse{}).FromModel(plant)
@@ -43,52 +41,45 @@ func (s *PlantService) CreatePlant(plantRequest *dto.PlantCreateRequest, userID
 }
 
 func (s *PlantService) UpdatePlant(plant *dto.PlantUpdateRequest, plantId int64, userID int64) error {
-	var existingPlant models.Plant
-	result := s.db.Where("id = ? AND user_id = ?", plantId, userID).First(&existingPlant)
-	if result.Error != nil {
-		return result.Error
+	if _, err := s.plants.FindByID(plantId, userID); err != nil {
+		return err
 	}
 
 	updateModel := plant.ToModel(userID)
 	updateModel.ID = plantId
 
-	result = s.db.Model(&existingPlant).Updates(updateModel)
-	return result.Error
+	return s.plants.Update(updateModel)
 }
 
 func (s *PlantService) DeletePlant(userID int64, plantID int64) error {
-	var plant models.Plant
-	result := s.db.Where("id = ? AND user_id = ?", plantID, userID).First(&plant)
-	if result.Error != nil {
-		return result.Error
+	plant, err := s.plants.FindByID(plantID, userID)
+	if err != nil {
+		return err
 	}
 
-	result = s.db.Delete(&plant)
-	return result.Error
+	return s.plants.Delete(plant)
 }
 
 func (s *PlantService) GetPlant(plantID int64, userID int64) (*dto.PlantResponse, error) {
-	var plant models.Plant
 	if plantID == 0 {
 		return nil, errors.New("plantID must be set")
 	}
-	result := s.db.Where("id = ? AND user_id = ?", plantID, userID).First(&plant)
-	if result.Error != nil {
-		return nil, result.Error
+	plant, err := s.plants.FindByID(plantID, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	response := (&dto.PlantResponse{}).FromModel(&plant)
+	response := (&dto.PlantResponse{}).FromModel(plant)
 	return response, nil
 }
 
 func (s *PlantService) GetPlants(userID int64) ([]dto.PlantResponse, error) {
-	var plants []models.Plant
 	if userID == 0 {
 		return nil, errors.New("userID must be set")
 	}
-	result := s.db.Where("user_id = ?", userID).Find(&plants)
-	if result.Error != nil {
-		return nil, result.Error
+	plants, err := s.plants.FindByUser(userID)
+	if err != nil {
+		return nil, err
 	}
 
 	return dto.FromPlantsModel(plants), nil
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"plant-reminder/utils"
+	"time"
+)
+
+// calculateNextTriggerTime sets the reminder's next trigger time, evaluating its
+// time of day on the wall clock of the owner's time zone. NextTriggerTime is stored in UTC.
+// A pending snooze replaces the occurrences up to it, so the regular schedule
+// resumes after the snooze.
+func (s *ReminderService) calculateNextTriggerTime(reminder *models.Reminder, loc *time.Location) error {
+	after := time.Now()
+	if reminder.SnoozedUntil != nil && reminder.SnoozedUntil.After(after) {
+		after = *reminder.SnoozedUntil
+	}
+	nextTime, err := nextOccurrence(reminder, loc, after)
+	if err != nil {
+		return err
+	}
+
+	reminder.NextTriggerTime = nextTime.UTC()
+	return nil
+}
+
+// nextOccurrence returns the first time strictly after `after` at which the reminder fires.
+func nextOccurrence(reminder *models.Reminder, loc *time.Location, after time.Time) (time.Time, error) {
+	now := after.In(loc)
+
+	if reminder.Repeat == constants.RepeatCron {
+		schedule, err := utils.ParseCronExpression(reminder.CronExpression)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return schedule.Next(now), nil
+	}
+
+	t, err := time.Parse("15:04", reminder.TimeOfDay)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time format, expected HH:mm: %w", err)
+	}
+
+	nextTime := wallClock(now.Year(), now.Month(), now.Day(), t, loc)
+
+	switch reminder.Repeat {
+	case constants.RepeatDaily:
+		if !nextTime.After(now) {
+			nextTime = wallClock(now.Year(), now.Month(), now.Day()+1, t, loc)
+		}
+
+	case constants.RepeatWeekly:
+		days := weekdays(reminder)
+		if len(days) == 0 {
+			return time.Time{}, errors.New("weekly reminder requires dayOfWeek")
+		}
+
+		// The first matching weekday within the next eight days always exists.
+		for offset := 0; offset <= 7; offset++ {
+			candidate := wallClock(now.Year(), now.Month(), now.Day()+offset, t, loc)
+			if days[candidate.Weekday()] && candidate.After(now) {
+				nextTime = candidate
+				break
+			}
+		}
+
+	case constants.RepeatMonthly:
+		if reminder.DayOfMonth == nil {
+			return time.Time{}, errors.New("monthly reminder requires dayOfMonth")
+		}
+		day := int(*reminder.DayOfMonth)
+
+		nextTime = dayOfMonth(now.Year(), now.Month(), day, t, loc)
+		if !nextTime.After(now) {
+			nextTime = dayOfMonth(now.Year(), now.Month()+1, day, t, loc)
+		}
+
+	case constants.RepeatEveryNDays, constants.RepeatEveryNWeeks:
+		if reminder.Interval == nil || *reminder.Interval < 1 {
+			return time.Time{}, fmt.Errorf("%s reminder requires a positive interval", reminder.Repeat)
+		}
+		stepDays := int(*reminder.Interval)
+		if reminder.Repeat == constants.RepeatEveryNWeeks {
+			stepDays *= 7
+		}
+
+		nextTime = nextInterval(intervalAnchor(reminder, now), stepDays, t, now)
+
+	default:
+		return time.Time{}, fmt.Errorf("unsupported repeat type: %d", reminder.Repeat)
+	}
+
+	return nextTime, nil
+}
+
+// weekdays returns the set of weekdays a weekly reminder fires on. Reminders created
+// before multi-day support only have DayOfWeek.
+func weekdays(reminder *models.Reminder) map[time.Weekday]bool {
+	days := map[time.Weekday]bool{}
+	if reminder.DayOfWeek != nil {
+		days[time.Weekday(*reminder.DayOfWeek)] = true
+	}
+	for _, day := range reminder.DaysOfWeek {
+		days[time.Weekday(day)] = true
+	}
+	return days
+}
+
+// sameSchedule reports whether two reminders fire at exactly the same times.
+func sameSchedule(a, b *models.Reminder) bool {
+	if a.Repeat != b.Repeat || a.TimeOfDay != b.TimeOfDay || a.CronExpression != b.CronExpression {
+		return false
+	}
+	if !equalInt16(a.DayOfMonth, b.DayOfMonth) || !equalInt16(a.Interval, b.Interval) {
+		return false
+	}
+	return maps.Equal(weekdays(a), weekdays(b))
+}
+
+func equalInt16(a, b *int16) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// wallClock returns the given day at the given time of day in loc. Out-of-range days
+// are normalized like time.Date does. A time of day that falls into a DST gap (e.g.
+// 02:30 on a spring-forward night) is moved forward by the length of the gap;
+// ambiguous fall-back times are resolved by time.Date.
+func wallClock(year int, month time.Month, day int, timeOfDay time.Time, loc *time.Location) time.Time {
+	result := time.Date(year, month, day, timeOfDay.Hour(), timeOfDay.Minute(), 0, 0, loc)
+
+	wanted := time.Date(year, month, day, timeOfDay.Hour(), timeOfDay.Minute(), 0, 0, time.UTC)
+	got := time.Date(result.Year(), result.Month(), result.Day(), result.Hour(), result.Minute(), 0, 0, time.UTC)
+	if skew := wanted.Sub(got); skew > 0 {
+		result = result.Add(skew)
+	}
+	return result
+}
+
+// dayOfMonth builds the given day of the month, clamped to the month's last day.
+func dayOfMonth(year int, month time.Month, day int, timeOfDay time.Time, loc *time.Location) time.Time {
+	daysInMonth := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > daysInMonth {
+		day = daysInMonth
+	}
+	return wallClock(year, month, day, timeOfDay, loc)
+}
+
+// intervalAnchor is the moment interval reminders count from: the last completion
+// if there is one, the creation of the reminder otherwise.
+func intervalAnchor(reminder *models.Reminder, now time.Time) time.Time {
+	if reminder.LastCompletedAt != nil {
+		return reminder.LastCompletedAt.In(now.Location())
+	}
+	if !reminder.CreatedAt.IsZero() {
+		return reminder.CreatedAt.In(now.Location())
+	}
+	return now
+}
+
+// nextInterval returns the first occurrence after now that lies a whole number of
+// steps (at least one) after the anchor's calendar day.
+func nextInterval(anchor time.Time, stepDays int, timeOfDay time.Time, now time.Time) time.Time {
+	occurrence := func(steps int) time.Time {
+		return wallClock(anchor.Year(), anchor.Month(), anchor.Day()+steps*stepDays, timeOfDay, now.Location())
+	}
+
+	steps := 1
+	if elapsedDays := int(now.Sub(anchor).Hours() / 24); elapsedDays > stepDays {
+		steps = elapsedDays / stepDays
+	}
+
+	nextTime := occurrence(steps)
+	for !nextTime.After(now) {
+		steps++
+		nextTime = occurrence(steps)
+	}
+	return nextTime
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"plant-reminder/constants"
+	"plant-reminder/models"
+	"testing"
+	"time"
+)
+
+func int16Ptr(v int16) *int16 {
+	return &v
+}
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("Failed to load %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestNextOccurrence_TimeZones(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	local := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, newYork)
+	}
+
+	// In 2026 New York springs forward on March 8 (02:00 EST -> 03:00 EDT) and
+	// falls back on November 1 (02:00 EDT -> 01:00 EST).
+	tests := []struct {
+		name     string
+		reminder models.Reminder
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:     "daily in the spring-forward gap moves past the gap",
+			reminder: models.Reminder{Repeat: constants.RepeatDaily, TimeOfDay: "02:30"},
+			after:    local(2026, time.March, 7, 12, 0),
+			want:     utc(2026, time.March, 8, 7, 30), // 03:30 EDT
+		},
+		{
+			name:     "daily in the spring-forward gap is back to normal the next day",
+			reminder: models.Reminder{Repeat: constants.RepeatDaily, TimeOfDay: "02:30"},
+			after:    utc(2026, time.March, 8, 7, 30),
+			want:     utc(2026, time.March, 9, 6, 30), // 02:30 EDT
+		},
+		{
+			name:     "daily in the fall-back overlap fires at the first 01:30",
+			reminder: models.Reminder{Repeat: constants.RepeatDaily, TimeOfDay: "01:30"},
+			after:    local(2026, time.October, 31, 12, 0),
+			want:     utc(2026, time.November, 1, 5, 30), // 01:30 EDT
+		},
+		{
+			name:     "daily in the fall-back overlap doesn't fire again an hour later",
+			reminder: models.Reminder{Repeat: constants.RepeatDaily, TimeOfDay: "01:30"},
+			after:    utc(2026, time.November, 1, 5, 30),
+			want:     utc(2026, time.November, 2, 6, 30), // 01:30 EST
+		},
+		{
+			name:     "daily keeps its wall clock time across spring forward",
+			reminder: models.Reminder{Repeat: constants.RepeatDaily, TimeOfDay: "08:00"},
+			after:    local(2026, time.March, 7, 8, 0),
+			want:     utc(2026, time.March, 8, 12, 0), // 08:00 EDT, 23 hours later
+		},
+		{
+			name:     "daily keeps its wall clock time across fall back",
+			reminder: models.Reminder{Repeat: constants.RepeatDaily, TimeOfDay: "08:00"},
+			after:    local(2026, time.October, 31, 8, 0),
+			want:     utc(2026, time.November, 1, 13, 0), // 08:00 EST, 25 hours later
+		},
+		{
+			name:     "daily later the same day",
+			reminder: models.Reminder{Repeat: constants.RepeatDaily, TimeOfDay: "20:00"},
+			after:    local(2026, time.June, 10, 19, 59),
+			want:     local(2026, time.June, 10, 20, 0),
+		},
+		{
+			name:     "monthly on the 31st is clamped to the end of February",
+			reminder: models.Reminder{Repeat: constants.RepeatMonthly, TimeOfDay: "09:00", DayOfMonth: int16Ptr(31)},
+			after:    local(2026, time.January, 31, 9, 0),
+			want:     local(2026, time.February, 28, 9, 0),
+		},
+		{
+			name:     "monthly on the 31st returns to the 31st after February",
+			reminder: models.Reminder{Repeat: constants.RepeatMonthly, TimeOfDay: "09:00", DayOfMonth: int16Ptr(31)},
+			after:    local(2026, time.February, 28, 9, 0),
+			want:     local(2026, time.March, 31, 9, 0),
+		},
+		{
+			name:     "monthly on the 31st in a leap year",
+			reminder: models.Reminder{Repeat: constants.RepeatMonthly, TimeOfDay: "09:00", DayOfMonth: int16Ptr(31)},
+			after:    local(2028, time.January, 31, 9, 0),
+			want:     local(2028, time.February, 29, 9, 0),
+		},
+		{
+			name:     "monthly on the 31st from December to January",
+			reminder: models.Reminder{Repeat: constants.RepeatMonthly, TimeOfDay: "09:00", DayOfMonth: int16Ptr(31)},
+			after:    local(2026, time.December, 31, 9, 0),
+			want:     local(2027, time.January, 31, 9, 0),
+		},
+		{
+			name:     "monthly on the 31st is clamped in a 30-day month",
+			reminder: models.Reminder{Repeat: constants.RepeatMonthly, TimeOfDay: "09:00", DayOfMonth: int16Ptr(31)},
+			after:    local(2026, time.April, 1, 0, 0),
+			want:     local(2026, time.April, 30, 9, 0),
+		},
+	}
+
+	for _, test := range tests {
+		got, err := nextOccurrence(&test.reminder, newYork, test.after)
+		if err != nil {
+			t.Errorf("%s: nextOccurrence failed: %v", test.name, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want.In(newYork), got.In(newYork))
+		}
+	}
+}
+
+func TestNextOccurrence_Intervals(t *testing.T) {
+	kyiv := loadLocation(t, "Europe/Kyiv")
+	local := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, kyiv)
+	}
+	timePtr := func(t time.Time) *time.Time {
+		return &t
+	}
+	everyThreeDays := func(createdAt time.Time, lastCompletedAt *time.Time) models.Reminder {
+		return models.Reminder{
+			Repeat:          constants.RepeatEveryNDays,
+			TimeOfDay:       "09:00",
+			Interval:        int16Ptr(3),
+			CreatedAt:       createdAt,
+			LastCompletedAt: lastCompletedAt,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		reminder models.Reminder
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:     "counts from the creation without a completion",
+			reminder: everyThreeDays(local(time.May, 1, 12, 0), nil),
+			after:    local(time.May, 1, 12, 0),
+			want:     local(time.May, 4, 9, 0),
+		},
+		{
+			name:     "counts from now if the creation time is unknown",
+			reminder: everyThreeDays(time.Time{}, nil),
+			after:    local(time.May, 2, 12, 0),
+			want:     local(time.May, 5, 9, 0),
+		},
+		{
+			name:     "a completion moves the anchor",
+			reminder: everyThreeDays(local(time.May, 1, 12, 0), timePtr(local(time.May, 6, 18, 0))),
+			after:    local(time.May, 6, 18, 0),
+			want:     local(time.May, 9, 9, 0),
+		},
+		{
+			name:     "a completion before the due day moves the next occurrence closer",
+			reminder: everyThreeDays(local(time.May, 1, 12, 0), timePtr(local(time.May, 2, 7, 0))),
+			after:    local(time.May, 2, 7, 0),
+			want:     local(time.May, 5, 9, 0),
+		},
+		{
+			name:     "several intervals past the anchor catches up on the step grid",
+			reminder: everyThreeDays(local(time.May, 1, 12, 0), nil),
+			after:    local(time.May, 11, 10, 0),
+			want:     local(time.May, 13, 9, 0),
+		},
+		{
+			name:     "an occurrence that just fired is not returned again",
+			reminder: everyThreeDays(local(time.May, 1, 12, 0), nil),
+			after:    local(time.May, 10, 9, 0),
+			want:     local(time.May, 13, 9, 0),
+		},
+		{
+			name: "every two weeks counts from the creation",
+			reminder: models.Reminder{
+				Repeat:    constants.RepeatEveryNWeeks,
+				TimeOfDay: "09:00",
+				Interval:  int16Ptr(2),
+				CreatedAt: local(time.May, 4, 12, 0),
+			},
+			after: local(time.May, 4, 12, 0),
+			want:  local(time.May, 18, 9, 0),
+		},
+		{
+			name: "every two weeks counts from the last completion",
+			reminder: models.Reminder{
+				Repeat:          constants.RepeatEveryNWeeks,
+				TimeOfDay:       "09:00",
+				Interval:        int16Ptr(2),
+				CreatedAt:       local(time.May, 4, 12, 0),
+				LastCompletedAt: timePtr(local(time.May, 20, 8, 0)),
+			},
+			after: local(time.May, 20, 8, 0),
+			want:  local(time.June, 3, 9, 0),
+		},
+		{
+			name: "every two weeks several intervals past the anchor",
+			reminder: models.Reminder{
+				Repeat:    constants.RepeatEveryNWeeks,
+				TimeOfDay: "09:00",
+				Interval:  int16Ptr(2),
+				CreatedAt: local(time.May, 4, 12, 0),
+			},
+			after: local(time.June, 20, 12, 0),
+			want:  local(time.June, 29, 9, 0),
+		},
+	}
+
+	for _, test := range tests {
+		got, err := nextOccurrence(&test.reminder, kyiv, test.after)
+		if err != nil {
+			t.Errorf("%s: nextOccurrence failed: %v", test.name, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want, got.In(kyiv))
+		}
+	}
+
+	invalid := models.Reminder{Repeat: constants.RepeatEveryNDays, TimeOfDay: "09:00", Interval: int16Ptr(0)}
+	if _, err := nextOccurrence(&invalid, kyiv, local(time.May, 1, 12, 0)); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
+
+func TestNextOccurrence_WeeklyAndCron(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	local := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, newYork)
+	}
+	// Monday, Wednesday and Friday. June 10, 2026 is a Wednesday.
+	weekdays := models.Reminder{Repeat: constants.RepeatWeekly, TimeOfDay: "08:00", DaysOfWeek: []int16{1, 3, 5}}
+	workdays := models.Reminder{Repeat: constants.RepeatCron, CronExpression: "30 7 * * 1-5"}
+
+	tests := []struct {
+		name     string
+		reminder models.Reminder
+		after    time.Time
+		want     time.Time
+	}{
+		{
+			name:     "weekly fires later the same day",
+			reminder: weekdays,
+			after:    local(time.June, 10, 7, 0),
+			want:     local(time.June, 10, 8, 0),
+		},
+		{
+			name:     "weekly moves on to the next day once today's time passed",
+			reminder: weekdays,
+			after:    local(time.June, 10, 8, 0),
+			want:     local(time.June, 12, 8, 0),
+		},
+		{
+			name:     "weekly wraps around to the next week",
+			reminder: weekdays,
+			after:    local(time.June, 12, 9, 0),
+			want:     local(time.June, 15, 8, 0),
+		},
+		{
+			name:     "weekly on a single legacy dayOfWeek waits a whole week",
+			reminder: models.Reminder{Repeat: constants.RepeatWeekly, TimeOfDay: "08:00", DayOfWeek: int16Ptr(3)},
+			after:    local(time.June, 10, 9, 0),
+			want:     local(time.June, 17, 8, 0),
+		},
+		{
+			name:     "weekly keeps its wall clock time across spring forward",
+			reminder: models.Reminder{Repeat: constants.RepeatWeekly, TimeOfDay: "08:00", DaysOfWeek: []int16{0}},
+			after:    local(time.March, 7, 12, 0),
+			want:     time.Date(2026, time.March, 8, 12, 0, 0, 0, time.UTC), // 08:00 EDT
+		},
+		{
+			name:     "cron fires later the same day",
+			reminder: workdays,
+			after:    local(time.June, 8, 7, 0),
+			want:     local(time.June, 8, 7, 30),
+		},
+		{
+			name:     "cron skips the weekend",
+			reminder: workdays,
+			after:    local(time.June, 12, 8, 0),
+			want:     local(time.June, 15, 7, 30),
+		},
+		{
+			name:     "cron on a day of the month",
+			reminder: models.Reminder{Repeat: constants.RepeatCron, CronExpression: "0 9 1 * *"},
+			after:    local(time.June, 1, 9, 0),
+			want:     local(time.July, 1, 9, 0),
+		},
+		{
+			name:     "cron runs on the owner's wall clock across spring forward",
+			reminder: models.Reminder{Repeat: constants.RepeatCron, CronExpression: "0 8 * * *"},
+			after:    local(time.March, 7, 8, 0),
+			want:     time.Date(2026, time.March, 8, 12, 0, 0, 0, time.UTC), // 08:00 EDT
+		},
+	}
+
+	for _, test := range tests {
+		got, err := nextOccurrence(&test.reminder, newYork, test.after)
+		if err != nil {
+			t.Errorf("%s: nextOccurrence failed: %v", test.name, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("%s: expected %s, got %s", test.name, test.want.In(newYork), got.In(newYork))
+		}
+	}
+}
@@ -6,35 +6,46 @@ import (
 	"plant-reminder/constants"
 	"plant-reminder/dto"
 	"plant-reminder/models"
+	"plant-reminder/repository"
 	"plant-reminder/utils"
-	"sync"
 	"time"
 
 	"github.com/go-co-op/gocron"
-	"gorm.io/gorm"
 )
 
 var scheduler *gocron.Scheduler
 
+var testNotification = utils.Notification{
+	Title: "Hello from Plantie!",
+	Body:  "Notifications are working",
+}
+
 type ReminderService struct {
-	plantService *PlantService
-	db           *gorm.DB
+	plantService  *PlantService
+	outboxService *OutboxService
+	reminders     repository.ReminderRepository
+	users         repository.UserRepository
+	config        DispatchConfig
 }
 
 type ReminderServiceInterface interface {
 	CreateReminder(reminderRequest *dto.ReminderCreateRequest, plantId int64, userID int64) (*dto.ReminderResponse, error)
 	GetReminder(reminderID int64, userID int64) (*dto.ReminderResponse, error)
-	GetPlantReminders(plantID int64, userID int64) ([]dto.ReminderResponse, error)
-	GetUserReminders(userID int64) ([]dto.ReminderResponse, error)
+	GetPlantReminders(plantID int64, userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error)
+	GetUserReminders(userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error)
 	UpdateReminder(reminder *dto.ReminderUpdateRequest, userID int64, plantId int64) (*dto.ReminderResponse, error)
 	DeleteReminder(reminderID int64, userID int64) error
 	TestReminder(userId int64) error
+	SnoozeReminder(reminderID int64, plantID int64, userID int64, duration time.Duration) (*dto.ReminderResponse, error)
 }
 
-func NewReminderService(ps *PlantService, db *gorm.DB) *ReminderService {
+func NewReminderService(ps *PlantService, obs *OutboxService, reminders repository.ReminderRepository, users repository.UserRepository, config DispatchConfig) *ReminderService {
 	return &ReminderService{
-		plantService: ps,
-		db:           db,
+		plantService:  ps,
+		outboxService: obs,
+		reminders:     reminders,
+		users:         users,
+		config:        config.withDefaults(),
 	}
 }
 
@@ -45,29 +56,25 @@ func (s *ReminderService) CreateReminder(reminderRequest *dto.ReminderCreateRequ
 	}
 
 	reminder := reminderRequest.ToModel(userID)
+	reminder.PlantID = plantId
 
-	var existing models.Reminder
-	err = s.db.
-		Where("plant_id = ? AND time_of_day = ? AND repeat = ?", plantId, reminder.TimeOfDay, reminder.Repeat).
-		First(&existing).Error
-
-	if err == nil && existing.ID != reminder.ID {
-		return nil, errors.New("reminder with same time and repeat period already exists")
-	}
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("failed to check existing reminders: %w", err)
+	if err := s.checkDuplicate(reminder); err != nil {
+		return nil, err
 	}
 
-	if err := s.calculateNextTriggerTime(reminder); err != nil {
+	user, err := s.getUser(userID)
+	if err != nil {
 		return nil, err
 	}
 
-	reminder.PlantID = plantId
+	if err := s.calculateNextTriggerTime(reminder, utils.LoadLocation(user.TimeZone)); err != nil {
+		return nil, err
+	}
 
-	result := s.db.Create(reminder)
-	if result.Error != nil {
-		return nil, result.Error
+	if err := s.reminders.Create(reminder); err != nil {
+		return nil, err
 	}
+	reminder.User = user
 
 	response := (&dto.ReminderResponse{}).FromModel(reminder)
 	return response, nil
@@ -88,69 +95,110 @@ func (s *ReminderService) UpdateReminder(reminderRequest *dto.ReminderUpdateRequ
 	}
 
 	reminder := reminderRequest.ToModel(userID, plantID)
+	reminder.CreatedAt = existingReminder.CreatedAt
+	reminder.LastCompletedAt = existingReminder.LastCompletedAt
+	// A pending snooze outlives edits; the new schedule resumes after it.
+	reminder.SnoozedUntil = existingReminder.SnoozedUntil
 
-	var existing models.Reminder
-	err = s.db.
-		Where("plant_id = ? AND time_of_day = ? AND repeat = ? AND id != ?", plantID, reminder.TimeOfDay, reminder.Repeat, reminderRequest.ID).
-		First(&existing).Error
-
-	if err == nil {
-		return nil, errors.New("reminder with same time and repeat period already exists")
+	if err := s.checkDuplicate(reminder); err != nil {
+		return nil, err
 	}
-	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("failed to check existing reminders: %w", err)
+
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.calculateNextTriggerTime(reminder); err != nil {
+	if err := s.calculateNextTriggerTime(reminder, utils.LoadLocation(user.TimeZone)); err != nil {
 		return nil, err
 	}
 
-	result := s.db.Model(&existingReminder).Save(reminder)
+	err = s.reminders.Save(reminder)
+	reminder.User = user
 	response := (&dto.ReminderResponse{}).FromModel(reminder)
-	return response, result.Error
+	return response, err
+}
+
+// checkDuplicate rejects a reminder whose task and schedule are identical to another
+// reminder of the same plant. Reminders sharing a time but firing on different days
+// or for different tasks are allowed.
+func (s *ReminderService) checkDuplicate(reminder *models.Reminder) error {
+	candidates, err := s.reminders.FindSameSlot(reminder)
+	if err != nil {
+		return fmt.Errorf("failed to check existing reminders: %w", err)
+	}
+
+	for i := range candidates {
+		if sameSchedule(&candidates[i], reminder) {
+			return errors.New("reminder with same time and repeat period already exists")
+		}
+	}
+	return nil
 }
 
 func (s *ReminderService) DeleteReminder(reminderID, userID int64) error {
-	var reminder models.Reminder
-	result := s.db.Where("id = ? AND user_id = ?", reminderID, userID).First(&reminder)
-	if result.Error != nil {
-		return result.Error
+	reminder, err := s.reminders.FindForUser(reminderID, userID)
+	if err != nil {
+		return err
 	}
-	result = s.db.Delete(&reminder)
-	return result.Error
+	return s.reminders.Delete(reminder)
 }
 
-func (s *ReminderService) GetPlantReminders(plantID int64, userID int64) ([]dto.ReminderResponse, error) {
-	var reminders []models.Reminder
+// SnoozeReminder sets a one-off trigger that fires once after the given duration.
+// It replaces the regular occurrences until then, the schedule resumes afterwards.
+func (s *ReminderService) SnoozeReminder(reminderID int64, plantID int64, userID int64, duration time.Duration) (*dto.ReminderResponse, error) {
+	reminder, err := s.reminders.FindForPlant(reminderID, plantID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errors.New("reminder doesn't exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snoozedUntil := time.Now().Add(duration).UTC()
+	reminder.SnoozedUntil = &snoozedUntil
+	if err := s.calculateNextTriggerTime(reminder, reminderLocation(reminder)); err != nil {
+		return nil, err
+	}
+	if err := s.reminders.UpdateColumns(reminder, "snoozed_until", "next_trigger_time"); err != nil {
+		return nil, err
+	}
+
+	response := (&dto.ReminderResponse{}).FromModel(reminder)
+	return response, nil
+}
+
+// GetPlantReminders lists the plant's reminders, optionally only those of one task type.
+func (s *ReminderService) GetPlantReminders(plantID int64, userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error) {
 	if userID == 0 {
 		return nil, errors.New("userID must be set")
 	}
 	if plantID == 0 {
 		return nil, errors.New("plantID must be set")
 	}
-	result := s.db.Where("plant_id = ? AND user_id = ?", plantID, userID).Find(&reminders)
-	if result.Error != nil {
-		return nil, result.Error
+	reminders, err := s.reminders.FindByPlant(plantID, userID, taskType)
+	if err != nil {
+		return nil, err
 	}
 
 	return dto.FromRemindersModel(reminders), nil
 }
 
-func (s *ReminderService) GetUserReminders(userID int64) ([]dto.ReminderResponse, error) {
-	var reminders []models.Reminder
+// GetUserReminders lists all reminders of the user, optionally only those of one task type.
+func (s *ReminderService) GetUserReminders(userID int64, taskType constants.TaskType) ([]dto.ReminderResponse, error) {
 	if userID == 0 {
 		return nil, errors.New("userID must be set")
 	}
-	result := s.db.Preload("Plant").Where("user_id = ?", userID).Find(&reminders)
-	if result.Error != nil {
-		return nil, result.Error
+	reminders, err := s.reminders.FindByUser(userID, taskType)
+	if err != nil {
+		return nil, err
 	}
 
 	return dto.FromRemindersModel(reminders), nil
 }
 
 func (s *ReminderService) GetAllReminders(userID int64) ([]dto.ReminderResponse, error) {
-	return s.GetUserReminders(userID)
+	return s.GetUserReminders(userID, "")
 }
 
 func (s *ReminderService) SetReminders() error {
@@ -158,7 +206,7 @@ func (s *ReminderService) SetReminders() error {
 		return nil
 	}
 	scheduler = gocron.NewScheduler(time.UTC)
-	_, err := scheduler.Every(1).Minutes().Do(func() {
+	_, err := scheduler.Every(s.config.CheckInterval).Do(func() {
 		errChan := make(chan error)
 		go s.checkReminders(errChan)
 		for err := range errChan {
@@ -170,152 +218,340 @@ func (s *ReminderService) SetReminders() error {
 	if err != nil {
 		return err
 	}
+	_, err = scheduler.Every(s.config.OutboxInterval).Do(func() {
+		if err := s.outboxService.ProcessOutbox(); err != nil {
+			fmt.Println("error during processing notification outbox", err)
+		}
+	})
+	if err != nil {
+		return err
+	}
 	scheduler.StartAsync()
 	return nil
 }
 
-func (s *ReminderService) getReminder(reminderID int64) (models.Reminder, error) {
-	var reminder models.Reminder
+func (s *ReminderService) getReminder(reminderID int64) (*models.Reminder, error) {
 	if reminderID == 0 {
-		return models.Reminder{}, errors.New("reminderID must be set")
+		return nil, errors.New("reminderID must be set")
 	}
-	result := s.db.Where("id = ?", reminderID).First(&reminder)
-	return reminder, result.Error
+	return s.reminders.FindByID(reminderID)
 }
 
-func (s *ReminderService) GetReminder(reminderID int64, userID int64) (*dto.ReminderResponse, error) {
-	var reminder models.Reminder
-	if reminderID == 0 {
-		return nil, errors.New("reminderID must be set")
+// markCompleted anchors interval reminders to the completion time, so the next
+// occurrence is counted from when the task was actually done.
+func (s *ReminderService) markCompleted(reminder *models.Reminder, completedAt time.Time) error {
+	if reminder.LastCompletedAt != nil && reminder.LastCompletedAt.After(completedAt) {
+		return nil
 	}
-	result := s.db.Where("id = ? AND user_id = ?", reminderID, userID).First(&reminder)
-	if result.Error != nil {
-		return nil, result.Error
+	reminder.LastCompletedAt = &completedAt
+
+	columns := []string{"last_completed_at"}
+	if reminder.Repeat.IsInterval() {
+		user, err := s.getUser(reminder.UserID)
+		if err != nil {
+			return err
+		}
+		if err := s.calculateNextTriggerTime(reminder, utils.LoadLocation(user.TimeZone)); err != nil {
+			return err
+		}
+		columns = append(columns, "next_trigger_time")
 	}
 
-	response := (&dto.ReminderResponse{}).FromModel(&reminder)
-	return response, nil
+	return s.reminders.UpdateColumns(reminder, columns...)
 }
 
-func (s *ReminderService) TestReminder(userID int64) error {
-	var user models.User
-	s.db.Where("id = ?", userID).First(&user)
-	if user.PushToken == "" {
-		return errors.New("user doesn't have push token")
-	}
-	utils.SendMessage(user.PushToken, "test")
-	return nil
+func (s *ReminderService) getUser(userID int64) (*models.User, error) {
+	return s.users.FindByID(userID)
 }
 
-func (s *ReminderService) calculateNextTriggerTime(reminder *models.Reminder) error {
-	now := time.Now()
+// RescheduleUserReminders recalculates every reminder of the user, e.g. after
+// the user changed their time zone.
+func (s *ReminderService) RescheduleUserReminders(userID int64) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	loc := utils.LoadLocation(user.TimeZone)
 
-	t, err := time.Parse("15:04", reminder.TimeOfDay)
+	reminders, err := s.reminders.FindByUser(userID, "")
 	if err != nil {
-		return fmt.Errorf("invalid time format, expected HH:mm: %w", err)
+		return err
+	}
+	if len(reminders) == 0 {
+		return nil
+	}
+
+	updated := make([]*models.Reminder, len(reminders))
+	for i := range reminders {
+		if err := s.calculateNextTriggerTime(&reminders[i], loc); err != nil {
+			return err
+		}
+		updated[i] = &reminders[i]
 	}
+	return s.reminders.Save(updated...)
+}
 
-	nextTime := time.Date(
-		now.Year(), now.Month(), now.Day(),
-		t.Hour(), t.Minute(), 0, 0, time.Local,
-	)
+// RescheduleAllReminders recalculates the next trigger time of every reminder that
+// isn't due yet, e.g. after a fix to the scheduling rules. Due reminders are left to
+// the dispatcher, so no occurrence is lost. It returns the number of changed reminders.
+func (s *ReminderService) RescheduleAllReminders() (int, error) {
+	now := time.Now()
+	changed := 0
 
-	switch reminder.Repeat {
-	case constants.RepeatDaily:
-		if nextTime.Before(now) {
-			nextTime = nextTime.Add(24 * time.Hour)
+	var afterID int64
+	for {
+		reminders, err := s.reminders.FindPage(afterID, s.config.BatchSize)
+		if err != nil {
+			return changed, err
 		}
 
-	case constants.RepeatWeekly:
-		if reminder.DayOfWeek == nil {
-			return errors.New("weekly reminder requires dayOfWeek")
+		for i := range reminders {
+			reminder := &reminders[i]
+			if !reminder.NextTriggerTime.After(now) {
+				continue
+			}
+			previous := reminder.NextTriggerTime
+			if err := s.calculateNextTriggerTime(reminder, reminderLocation(reminder)); err != nil {
+				return changed, fmt.Errorf("reminder %d: %w", reminder.ID, err)
+			}
+			if reminder.NextTriggerTime.Equal(previous) {
+				continue
+			}
+			// The page isn't locked: a reminder that fired, was snoozed or edited in
+			// the meantime already has an up to date trigger time and is skipped.
+			updated, err := s.reminders.Reschedule(reminder, previous)
+			if err != nil {
+				return changed, err
+			}
+			if updated {
+				changed++
+			}
 		}
-		targetWeekday := time.Weekday(*reminder.DayOfWeek)
 
-		daysUntil := (int(targetWeekday) - int(now.Weekday()) + 7) % 7
-		if daysUntil == 0 && nextTime.Before(now) {
-			daysUntil = 7
+		if len(reminders) < s.config.BatchSize {
+			return changed, nil
 		}
-		nextTime = nextTime.AddDate(0, 0, daysUntil)
+		afterID = reminders[len(reminders)-1].ID
+	}
+}
+
+// GetDueReminders lists the reminders of all users that are due by until, soonest
+// first, at most one dispatch batch of them.
+func (s *ReminderService) GetDueReminders(until time.Time) ([]dto.ReminderResponse, error) {
+	reminders, err := s.reminders.FindDue(until, s.config.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.FromRemindersModel(reminders), nil
+}
 
-	case constants.RepeatMonthly:
-		if reminder.DayOfMonth == nil {
-			return errors.New("monthly reminder requires dayOfMonth")
+func (s *ReminderService) GetReminder(reminderID int64, userID int64) (*dto.ReminderResponse, error) {
+	if reminderID == 0 {
+		return nil, errors.New("reminderID must be set")
+	}
+	reminder, err := s.reminders.FindForUser(reminderID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := (&dto.ReminderResponse{}).FromModel(reminder)
+	return response, nil
+}
+
+func (s *ReminderService) TestReminder(userID int64) error {
+	if s.config.VerifiedUsersOnly {
+		user, err := s.users.FindByID(userID)
+		if err != nil {
+			return err
+		}
+		if !user.EmailVerified {
+			return ErrEmailNotVerified
 		}
-		day := int(*reminder.DayOfMonth)
+	}
+	return s.outboxService.sendNow(userID, testNotification)
+}
 
-		daysInMonth := time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, time.Local).Day()
-		if day > daysInMonth {
-			day = daysInMonth
+// RunDispatch runs one scheduler tick outside the schedule: it claims the due
+// reminders and delivers the notifications they queued.
+func (s *ReminderService) RunDispatch() error {
+	ch := make(chan error)
+	go s.checkReminders(ch)
+	var checkErr error
+	for err := range ch {
+		if err != nil {
+			checkErr = err
 		}
+	}
+	if checkErr != nil {
+		return checkErr
+	}
+	return s.outboxService.ProcessOutbox()
+}
+
+func (s *ReminderService) checkReminders(ch chan error) {
+	defer close(ch)
+	start := time.Now()
+	claimed := 0
+	defer func() { recordTick(reminderMetrics, claimed, time.Since(start)) }()
+
+	var afterID int64
+	for {
+		lastID, n, err := s.claimDueReminders(start, afterID)
+		claimed += n
+		if err != nil {
+			ch <- err
+			return
+		}
+		if n < s.config.BatchSize {
+			break
+		}
+		afterID = lastID
+	}
+	ch <- nil
+}
+
+// claimDueReminders claims the next batch of due reminders with id > afterID, moves
+// them to their next occurrence, records missed occurrences and enqueues their
+// notifications, all in one transaction. Replicas running the scheduler concurrently
+// never claim the same row, and once the transaction commits the claimed occurrences
+// are no longer due. The outbox worker delivers the notifications. It returns the
+// highest claimed id and the number of claimed reminders.
+func (s *ReminderService) claimDueReminders(now time.Time, afterID int64) (int64, int, error) {
+	lastID := afterID
+	claimed := 0
+
+	err := s.reminders.ClaimDue(now, afterID, s.config.BatchSize, func(tx *repository.Tx, reminders []models.Reminder) ([]models.Reminder, error) {
+		claimed = len(reminders)
+
+		var missedEvents []models.CareEvent
+		var updatedReminders []models.Reminder
+		var notifications []reminderNotification
+		userIDs := make([]int64, 0, len(reminders))
+		for _, r := range reminders {
+			lastID = r.ID
+			loc := reminderLocation(&r)
+
+			missed := s.missedOccurrences(&r, loc, now)
+			for _, occurrence := range missed {
+				missedEvents = append(missedEvents, models.CareEvent{
+					ReminderID: r.ID,
+					PlantID:    r.PlantID,
+					UserID:     r.UserID,
+					Action:     constants.CareActionMissed,
+					Timestamp:  occurrence.UTC(),
+				})
+			}
+			if len(missed) == 0 || s.config.CatchUpPolicy != constants.CatchUpSkip {
+				notifications = append(notifications, reminderNotification{
+					reminderID:   r.ID,
+					userID:       r.UserID,
+					notification: s.buildNotification(&r, len(missed)),
+				})
+				userIDs = append(userIDs, r.UserID)
+			}
 
-		nextTime = time.Date(now.Year(), now.Month(), day, t.Hour(), t.Minute(), 0, 0, time.Local)
-		if nextTime.Before(now) {
-			nextMonth := now.AddDate(0, 1, 0)
-			daysInNextMonth := time.Date(nextMonth.Year(), nextMonth.Month()+1, 0, 0, 0, 0, 0, time.Local).Day()
-			if day > daysInNextMonth {
-				day = daysInNextMonth
+			// A fired snooze is a one-off; the regular recurrence continues from here.
+			if r.SnoozedUntil != nil && !r.SnoozedUntil.After(now) {
+				r.SnoozedUntil = nil
+			}
+			if err := s.calculateNextTriggerTime(&r, loc); err != nil {
+				fmt.Println("failed to recalc nextTriggerTime:", err)
+				continue
 			}
-			nextTime = time.Date(nextMonth.Year(), nextMonth.Month(), day, t.Hour(), t.Minute(), 0, 0, time.Local)
+			updatedReminders = append(updatedReminders, r)
 		}
 
-	default:
-		return fmt.Errorf("unsupported repeat type: %s", reminder.Repeat)
+		if err := s.enqueueNotifications(tx, notifications, userIDs, now); err != nil {
+			return nil, fmt.Errorf("failed to enqueue notifications: %w", err)
+		}
+		if err := tx.CareEvents.CreateMany(missedEvents); err != nil {
+			return nil, fmt.Errorf("failed to record missed reminders: %w", err)
+		}
+		return updatedReminders, nil
+	})
+	if err != nil {
+		return afterID, claimed, err
 	}
+	return lastID, claimed, nil
+}
 
-	reminder.NextTriggerTime = nextTime
-	return nil
+type reminderNotification struct {
+	reminderID   int64
+	userID       int64
+	notification utils.Notification
 }
 
-func (s *ReminderService) checkReminders(ch chan error) {
-	defer close(ch)
-	var reminders []models.Reminder
-	err := s.db.
-		Where("next_trigger_time <= ?", time.Now()).
-		Find(&reminders).Error
+// buildNotification renders the reminder's push message. missed is the number of
+// occurrences missed before this one.
+func (s *ReminderService) buildNotification(reminder *models.Reminder, missed int) utils.Notification {
+	plantName := ""
+	if reminder.Plant != nil {
+		plantName = reminder.Plant.Name
+	}
+	if missed != 0 && s.config.CatchUpPolicy == constants.CatchUpSummary {
+		return utils.MissedReminderNotification(reminder.TaskType, reminder.TaskLabel, plantName, missed)
+	}
+	return utils.ReminderNotification(reminder.TaskType, reminder.TaskLabel, plantName)
+}
 
+// enqueueNotifications puts the notifications into the outbox for all active devices
+// of their owners, loading the devices of the whole batch with one query.
+func (s *ReminderService) enqueueNotifications(tx *repository.Tx, notifications []reminderNotification, userIDs []int64, now time.Time) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	devices, err := s.outboxService.activeDevices(tx.Users, userIDs...)
 	if err != nil {
-		ch <- err
-		return
+		return err
+	}
+	devicesByUser := make(map[int64][]models.Device)
+	for _, device := range devices {
+		devicesByUser[device.UserID] = append(devicesByUser[device.UserID], device)
 	}
 
-	var wg sync.WaitGroup
-	for _, value := range reminders {
-		wg.Add(1)
-		go func(pid int64) {
-			defer wg.Done()
-			s.sendNotifications(pid)
-		}(value.PlantID)
+	var messages []models.OutboxMessage
+	for _, n := range notifications {
+		messages = append(messages, outboxMessages(n.reminderID, devicesByUser[n.userID], n.notification, now)...)
 	}
-	wg.Wait()
+	return tx.Outbox.Enqueue(messages)
+}
 
-	var updatedReminders []models.Reminder
-	for _, r := range reminders {
-		if err := s.calculateNextTriggerTime(&r); err != nil {
-			fmt.Println("failed to recalc nextTriggerTime:", err)
-			continue
-		}
-		updatedReminders = append(updatedReminders, r)
+// maxMissedOccurrences bounds how many missed occurrences of a single reminder are
+// recorded, so a long downtime with a frequent cron reminder stays cheap.
+const maxMissedOccurrences = 100
+
+// missedOccurrences returns the due occurrences of the reminder that are older than
+// the latest one, i.e. those the scheduler didn't get to in time. With the skip
+// policy the latest occurrence counts as missed as well, as nobody is notified.
+func (s *ReminderService) missedOccurrences(reminder *models.Reminder, loc *time.Location, now time.Time) []time.Time {
+	if reminder.NextTriggerTime.IsZero() || reminder.NextTriggerTime.After(now) {
+		return nil
 	}
 
-	var result *gorm.DB
-	if len(updatedReminders) != 0 {
-		result = s.db.Save(&updatedReminders)
+	due := []time.Time{reminder.NextTriggerTime}
+	for len(due) <= maxMissedOccurrences {
+		next, err := nextOccurrence(reminder, loc, due[len(due)-1])
+		if err != nil || next.After(now) {
+			break
+		}
+		due = append(due, next)
 	}
 
-	if result != nil {
-		ch <- result.Error
-	} else {
-		ch <- nil
+	if len(due) == 1 {
+		return nil
+	}
+	if s.config.CatchUpPolicy == constants.CatchUpSkip {
+		return due
 	}
+	return due[:len(due)-1]
 }
 
-func (s *ReminderService) sendNotifications(plantID int64) {
-	var plant models.Plant
-	s.db.Where("id = ?", plantID).First(&plant)
-	var user models.User
-	s.db.Where("id = ?", plant.UserID).First(&user)
-	if user.PushToken != "" {
-		utils.SendMessage(user.PushToken, plant.Name)
+// reminderLocation returns the time zone of the reminder's owner, UTC if User isn't loaded.
+func reminderLocation(reminder *models.Reminder) *time.Location {
+	if reminder.User == nil {
+		return time.UTC
 	}
+	return utils.LoadLocation(reminder.User.TimeZone)
 }
@@ -0,0 +1,527 @@
+package service
+
+import (
+	"fmt"
+	"plant-reminder/config"
+	"plant-reminder/constants"
+	"plant-reminder/dto"
+	"plant-reminder/migrations"
+	"plant-reminder/models"
+	"plant-reminder/repository"
+	"plant-reminder/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type testEnv struct {
+	db              *gorm.DB
+	notifier        *utils.RecordingNotifier
+	outboxService   *OutboxService
+	reminderService *ReminderService
+	user            *models.User
+	plant           *models.Plant
+}
+
+// setupTestEnv wires the services against an in-memory SQLite database with one
+// user, their plant and a registered device.
+func setupTestEnv(t *testing.T, dispatchConfig DispatchConfig) *testEnv {
+	t.Helper()
+
+	db, err := config.OpenDb(config.DatabaseConfig{Driver: config.DriverSQLite, URL: ":memory:"})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	user := &models.User{Email: "test@example.com", Name: "Test", TimeZone: "Europe/Kyiv"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	plant := &models.Plant{Name: "Monstera", UserID: user.ID, TagColor: "#00FF00", PlantIcon: models.BigPlant}
+	if err := db.Create(plant).Error; err != nil {
+		t.Fatalf("Failed to create plant: %v", err)
+	}
+	device := &models.Device{UserID: user.ID, Token: "token-1", Platform: "ios", LastSeenAt: time.Now()}
+	if err := db.Create(device).Error; err != nil {
+		t.Fatalf("Failed to create device: %v", err)
+	}
+
+	notifier := utils.NewRecordingNotifier()
+	userRepository := repository.NewUserRepository(db)
+	outboxService := NewOutboxService(repository.NewOutboxRepository(db), userRepository, notifier, dispatchConfig)
+	reminderService := NewReminderService(
+		NewPlantService(repository.NewPlantRepository(db)),
+		outboxService,
+		repository.NewReminderRepository(db),
+		userRepository,
+		dispatchConfig,
+	)
+
+	return &testEnv{
+		db:              db,
+		notifier:        notifier,
+		outboxService:   outboxService,
+		reminderService: reminderService,
+		user:            user,
+		plant:           plant,
+	}
+}
+
+func (env *testEnv) createDueReminder(t *testing.T, nextTriggerTime time.Time) *models.Reminder {
+	t.Helper()
+	reminder := &models.Reminder{
+		PlantID:         env.plant.ID,
+		UserID:          env.user.ID,
+		TaskType:        constants.TaskWater,
+		Repeat:          constants.RepeatDaily,
+		TimeOfDay:       nextTriggerTime.In(utils.LoadLocation(env.user.TimeZone)).Format("15:04"),
+		NextTriggerTime: nextTriggerTime,
+	}
+	if err := env.db.Create(reminder).Error; err != nil {
+		t.Fatalf("Failed to create reminder: %v", err)
+	}
+	return reminder
+}
+
+func (env *testEnv) checkReminders(t *testing.T) {
+	t.Helper()
+	ch := make(chan error)
+	go env.reminderService.checkReminders(ch)
+	for err := range ch {
+		if err != nil {
+			t.Fatalf("checkReminders failed: %v", err)
+		}
+	}
+}
+
+func TestReminderService_CheckReminders_DeliversThroughOutbox(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	reminder := env.createDueReminder(t, time.Now().Add(-time.Minute).Truncate(time.Minute))
+
+	env.checkReminders(t)
+
+	var messages []models.OutboxMessage
+	env.db.Find(&messages)
+	if len(messages) != 1 || messages[0].Status != constants.OutboxPending {
+		t.Fatalf("Expected one pending outbox message, got %+v", messages)
+	}
+
+	var updated models.Reminder
+	env.db.First(&updated, reminder.ID)
+	if !updated.NextTriggerTime.After(time.Now()) {
+		t.Errorf("Expected next trigger time in the future, got %s", updated.NextTriggerTime)
+	}
+
+	if err := env.outboxService.ProcessOutbox(); err != nil {
+		t.Fatalf("ProcessOutbox failed: %v", err)
+	}
+
+	sent := env.notifier.Messages()
+	if len(sent) != 1 {
+		t.Fatalf("Expected 1 sent notification, got %d", len(sent))
+	}
+	if sent[0].Token != "token-1" || sent[0].Notification.Body != "Time to water your plant Monstera" {
+		t.Errorf("Unexpected notification: %+v", sent[0])
+	}
+
+	env.db.First(&messages[0], messages[0].ID)
+	if messages[0].Status != constants.OutboxSent {
+		t.Errorf("Expected status sent, got %s", messages[0].Status)
+	}
+}
+
+func TestReminderService_CheckReminders_RecordsMissedOccurrences(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{CatchUpPolicy: constants.CatchUpSummary})
+	env.createDueReminder(t, time.Now().Add(-50*time.Hour).Truncate(time.Minute))
+
+	env.checkReminders(t)
+
+	var missed int64
+	env.db.Model(&models.CareEvent{}).Where("action = ?", constants.CareActionMissed).Count(&missed)
+	if missed != 2 {
+		t.Errorf("Expected 2 missed occurrences, got %d", missed)
+	}
+
+	if err := env.outboxService.ProcessOutbox(); err != nil {
+		t.Fatalf("ProcessOutbox failed: %v", err)
+	}
+	sent := env.notifier.Messages()
+	if len(sent) != 1 || !strings.HasPrefix(sent[0].Notification.Body, "You missed 2 waterings.") {
+		t.Errorf("Expected a single summary notification, got %+v", sent)
+	}
+}
+
+func TestReminderService_CheckReminders_LongOutage(t *testing.T) {
+	const reminders = 60
+	env := setupTestEnv(t, DispatchConfig{BatchSize: reminders})
+	overdue := time.Now().Add(-5 * 24 * time.Hour).Truncate(time.Hour)
+	for i := range reminders {
+		reminder := &models.Reminder{
+			PlantID:         env.plant.ID,
+			UserID:          env.user.ID,
+			TaskType:        constants.TaskMist,
+			Repeat:          constants.RepeatCron,
+			CronExpression:  fmt.Sprintf("%d * * * *", i),
+			NextTriggerTime: overdue.Add(time.Duration(i) * time.Minute),
+		}
+		if err := env.db.Create(reminder).Error; err != nil {
+			t.Fatalf("Failed to create reminder: %v", err)
+		}
+	}
+
+	// Every reminder missed more than maxMissedOccurrences hourly occurrences, more
+	// missed events than a single INSERT can take.
+	env.checkReminders(t)
+
+	var missed int64
+	env.db.Model(&models.CareEvent{}).Where("action = ?", constants.CareActionMissed).Count(&missed)
+	if missed != reminders*maxMissedOccurrences {
+		t.Errorf("Expected %d missed occurrences, got %d", reminders*maxMissedOccurrences, missed)
+	}
+	var due int64
+	env.db.Model(&models.Reminder{}).Where("next_trigger_time <= ?", time.Now()).Count(&due)
+	if due != 0 {
+		t.Errorf("Expected all reminders to be claimed, %d are still due", due)
+	}
+}
+
+func TestReminderService_CheckReminders_ManyDevices(t *testing.T) {
+	const reminders, devices = 60, 60
+	env := setupTestEnv(t, DispatchConfig{BatchSize: reminders})
+	for i := 1; i < devices; i++ {
+		device := &models.Device{UserID: env.user.ID, Token: fmt.Sprintf("token-%d", i+1), Platform: "android", LastSeenAt: time.Now()}
+		if err := env.db.Create(device).Error; err != nil {
+			t.Fatalf("Failed to create device: %v", err)
+		}
+	}
+	due := time.Now().Add(-time.Hour).Truncate(time.Hour)
+	for i := range reminders {
+		reminder := &models.Reminder{
+			PlantID:         env.plant.ID,
+			UserID:          env.user.ID,
+			TaskType:        constants.TaskMist,
+			Repeat:          constants.RepeatCron,
+			CronExpression:  fmt.Sprintf("%d * * * *", i),
+			NextTriggerTime: due.Add(time.Duration(i) * time.Second),
+		}
+		if err := env.db.Create(reminder).Error; err != nil {
+			t.Fatalf("Failed to create reminder: %v", err)
+		}
+	}
+
+	// One message per reminder and device, more than a single INSERT can take.
+	env.checkReminders(t)
+
+	var queued int64
+	env.db.Model(&models.OutboxMessage{}).Count(&queued)
+	if queued != reminders*devices {
+		t.Errorf("Expected %d queued messages, got %d", reminders*devices, queued)
+	}
+}
+
+func TestReminderService_CheckReminders_VerifiedUsersOnly(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{VerifiedUsersOnly: true})
+	env.createDueReminder(t, time.Now().Add(-time.Minute).Truncate(time.Minute))
+
+	env.checkReminders(t)
+
+	var queued int64
+	env.db.Model(&models.OutboxMessage{}).Count(&queued)
+	if queued != 0 {
+		t.Errorf("Expected no notifications for an unverified user, got %d", queued)
+	}
+	if err := env.reminderService.TestReminder(env.user.ID); err != ErrEmailNotVerified {
+		t.Errorf("Expected ErrEmailNotVerified for a test push, got %v", err)
+	}
+
+	env.db.Model(env.user).Update("email_verified", true)
+	env.createDueReminder(t, time.Now().Add(-time.Minute).Truncate(time.Minute))
+
+	env.checkReminders(t)
+
+	env.db.Model(&models.OutboxMessage{}).Count(&queued)
+	if queued != 1 {
+		t.Errorf("Expected one notification once the email is verified, got %d", queued)
+	}
+}
+
+func TestReminderService_SnoozeReminder_FiresOnceAndResumes(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	reminder := env.createDueReminder(t, time.Now().Add(time.Hour).Truncate(time.Minute))
+
+	if _, err := env.reminderService.SnoozeReminder(reminder.ID, env.plant.ID, env.user.ID, 24*time.Hour); err != nil {
+		t.Fatalf("SnoozeReminder failed: %v", err)
+	}
+
+	var snoozed models.Reminder
+	env.db.First(&snoozed, reminder.ID)
+	if snoozed.SnoozedUntil == nil {
+		t.Fatal("Expected snoozed_until to be set")
+	}
+	// The snooze replaces the regular occurrence in an hour instead of firing on top of it.
+	if !snoozed.NextTriggerTime.After(*snoozed.SnoozedUntil) {
+		t.Errorf("Expected the next regular trigger after the snooze at %s, got %s", snoozed.SnoozedUntil, snoozed.NextTriggerTime)
+	}
+
+	// Let the snooze come due.
+	env.db.Model(&snoozed).Update("snoozed_until", time.Now().Add(-time.Minute))
+	env.checkReminders(t)
+
+	var queued int64
+	env.db.Model(&models.OutboxMessage{}).Count(&queued)
+	if queued != 1 {
+		t.Errorf("Expected the snooze to queue 1 notification, got %d", queued)
+	}
+
+	var fired models.Reminder
+	env.db.First(&fired, reminder.ID)
+	if fired.SnoozedUntil != nil {
+		t.Errorf("Expected the snooze to be cleared, got %s", fired.SnoozedUntil)
+	}
+	loc := utils.LoadLocation(env.user.TimeZone)
+	if !fired.NextTriggerTime.After(time.Now()) || !fired.NextTriggerTime.Before(time.Now().Add(24*time.Hour)) ||
+		fired.NextTriggerTime.In(loc).Format("15:04") != reminder.TimeOfDay {
+		t.Errorf("Expected the daily recurrence at %s to resume, got %s", reminder.TimeOfDay, fired.NextTriggerTime.In(loc))
+	}
+
+	env.checkReminders(t)
+	env.db.Model(&models.OutboxMessage{}).Count(&queued)
+	if queued != 1 {
+		t.Errorf("Expected the snooze to fire only once, got %d notifications", queued)
+	}
+}
+
+func TestReminderService_UpdateReminder_KeepsSnooze(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	reminder := env.createDueReminder(t, time.Now().Add(time.Hour).Truncate(time.Minute))
+	if _, err := env.reminderService.SnoozeReminder(reminder.ID, env.plant.ID, env.user.ID, 24*time.Hour); err != nil {
+		t.Fatalf("SnoozeReminder failed: %v", err)
+	}
+	var snoozed models.Reminder
+	env.db.First(&snoozed, reminder.ID)
+
+	request := &dto.ReminderUpdateRequest{
+		ID:         reminder.ID,
+		TaskType:   constants.TaskWater,
+		TaskLabel:  "Rain water",
+		RepeatType: constants.RepeatDaily,
+		TimeOfDay:  reminder.TimeOfDay,
+	}
+	if _, err := env.reminderService.UpdateReminder(request, env.user.ID, env.plant.ID); err != nil {
+		t.Fatalf("UpdateReminder failed: %v", err)
+	}
+
+	var updated models.Reminder
+	env.db.First(&updated, reminder.ID)
+	if updated.TaskLabel != "Rain water" {
+		t.Errorf("Expected the label to be updated, got %q", updated.TaskLabel)
+	}
+	if updated.SnoozedUntil == nil || !updated.SnoozedUntil.Equal(*snoozed.SnoozedUntil) {
+		t.Fatalf("Expected the snooze until %s to be kept, got %v", snoozed.SnoozedUntil, updated.SnoozedUntil)
+	}
+	if !updated.NextTriggerTime.After(*updated.SnoozedUntil) {
+		t.Errorf("Expected the next regular trigger after the snooze, got %s", updated.NextTriggerTime)
+	}
+}
+
+func TestOutboxService_ProcessOutbox_RetriesAndDeadLetters(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	env.notifier.Result = utils.DeliveryResult{Status: utils.DeliveryTransientFailure}
+	env.createDueReminder(t, time.Now().Add(-time.Minute).Truncate(time.Minute))
+	env.checkReminders(t)
+
+	if err := env.outboxService.ProcessOutbox(); err != nil {
+		t.Fatalf("ProcessOutbox failed: %v", err)
+	}
+
+	var message models.OutboxMessage
+	env.db.First(&message)
+	if message.Status != constants.OutboxPending || message.Attempts != 1 {
+		t.Fatalf("Expected a pending message after 1 attempt, got %s after %d", message.Status, message.Attempts)
+	}
+	if !message.NextAttemptAt.After(time.Now()) {
+		t.Errorf("Expected the retry to be scheduled in the future, got %s", message.NextAttemptAt)
+	}
+
+	env.db.Model(&message).Updates(map[string]interface{}{
+		"attempts":        maxOutboxAttempts - 1,
+		"next_attempt_at": time.Now().Add(-time.Second),
+	})
+	if err := env.outboxService.ProcessOutbox(); err != nil {
+		t.Fatalf("ProcessOutbox failed: %v", err)
+	}
+
+	env.db.First(&message, message.ID)
+	if message.Status != constants.OutboxDead {
+		t.Errorf("Expected status dead after %d attempts, got %s", maxOutboxAttempts, message.Status)
+	}
+
+	stuck, err := env.outboxService.GetStuckMessages(constants.OutboxDead)
+	if err != nil || len(stuck) != 1 {
+		t.Errorf("Expected 1 dead message, got %d (%v)", len(stuck), err)
+	}
+}
+
+func TestOutboxService_ProcessOutbox_RemovesDeadDevices(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	env.notifier.Result = utils.DeliveryResult{Status: utils.DeliveryUnregistered}
+	env.createDueReminder(t, time.Now().Add(-time.Minute).Truncate(time.Minute))
+	env.checkReminders(t)
+
+	if err := env.outboxService.ProcessOutbox(); err != nil {
+		t.Fatalf("ProcessOutbox failed: %v", err)
+	}
+
+	var message models.OutboxMessage
+	env.db.First(&message)
+	if message.Status != constants.OutboxDead {
+		t.Errorf("Expected status dead for an unregistered token, got %s", message.Status)
+	}
+	var devices int64
+	env.db.Model(&models.Device{}).Where("user_id = ?", env.user.ID).Count(&devices)
+	if devices != 0 {
+		t.Errorf("Expected the dead device to be removed, got %d devices", devices)
+	}
+
+	env.notifier.Reset()
+	if err := env.reminderService.TestReminder(env.user.ID); err == nil {
+		t.Error("Expected a test push without devices to fail")
+	}
+	if sent := env.notifier.Messages(); len(sent) != 0 {
+		t.Errorf("Expected nothing sent to the removed device, got %+v", sent)
+	}
+}
+
+func TestOutboxService_ProcessOutbox_TransientFailureKeepsDevice(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	env.notifier.Result = utils.DeliveryResult{Status: utils.DeliveryTransientFailure}
+
+	if err := env.reminderService.TestReminder(env.user.ID); err == nil {
+		t.Error("Expected the failed test push to return an error")
+	}
+
+	var device models.Device
+	if err := env.db.Where("user_id = ?", env.user.ID).First(&device).Error; err != nil {
+		t.Fatalf("Expected the device to be kept: %v", err)
+	}
+	if device.DeliveryFailures != 1 {
+		t.Errorf("Expected 1 recorded delivery failure, got %d", device.DeliveryFailures)
+	}
+}
+
+func TestOutboxService_ProcessOutbox_InvalidMessageKeepsDevice(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	env.notifier.Result = utils.DeliveryResult{Status: utils.DeliveryInvalidMessage}
+	env.createDueReminder(t, time.Now().Add(-time.Minute).Truncate(time.Minute))
+	env.checkReminders(t)
+
+	if err := env.outboxService.ProcessOutbox(); err != nil {
+		t.Fatalf("ProcessOutbox failed: %v", err)
+	}
+
+	var message models.OutboxMessage
+	env.db.First(&message)
+	if message.Status != constants.OutboxDead || message.Attempts != 1 {
+		t.Errorf("Expected a rejected message to be dead after 1 attempt, got %s after %d", message.Status, message.Attempts)
+	}
+	var devices int64
+	env.db.Model(&models.Device{}).Where("user_id = ? AND delivery_failures = 0", env.user.ID).Count(&devices)
+	if devices != 1 {
+		t.Errorf("Expected the device to be kept untouched, got %d", devices)
+	}
+}
+
+func TestReminderService_CreateReminder_RejectsDuplicate(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	request := &dto.ReminderCreateRequest{RepeatType: constants.RepeatDaily, TimeOfDay: "08:00"}
+
+	created, err := env.reminderService.CreateReminder(request, env.plant.ID, env.user.ID)
+	if err != nil {
+		t.Fatalf("CreateReminder failed: %v", err)
+	}
+	if created.TimeZone != "Europe/Kyiv" || created.TaskType != constants.TaskWater {
+		t.Errorf("Unexpected reminder: %+v", created)
+	}
+
+	if _, err := env.reminderService.CreateReminder(request, env.plant.ID, env.user.ID); err == nil {
+		t.Error("Expected an error for a duplicate reminder")
+	}
+
+	request.TaskType = constants.TaskFertilize
+	if _, err := env.reminderService.CreateReminder(request, env.plant.ID, env.user.ID); err != nil {
+		t.Errorf("Expected a fertilizing reminder at the same time to be allowed, got %v", err)
+	}
+}
+
+func TestReminderService_RescheduleAllReminders_LeavesDueRemindersAlone(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{BatchSize: 1})
+	due := env.createDueReminder(t, time.Now().Add(-time.Minute).Truncate(time.Minute))
+	wrong := env.createDueReminder(t, time.Now().Add(240*time.Hour).Truncate(time.Minute))
+
+	changed, err := env.reminderService.RescheduleAllReminders()
+	if err != nil {
+		t.Fatalf("RescheduleAllReminders failed: %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("Expected 1 rescheduled reminder, got %d", changed)
+	}
+
+	var rescheduled, untouched models.Reminder
+	env.db.First(&rescheduled, wrong.ID)
+	if !rescheduled.NextTriggerTime.Before(time.Now().Add(24 * time.Hour)) {
+		t.Errorf("Expected the daily reminder within a day, got %s", rescheduled.NextTriggerTime)
+	}
+	env.db.First(&untouched, due.ID)
+	if !untouched.NextTriggerTime.Equal(due.NextTriggerTime) {
+		t.Errorf("Expected the due reminder to be left for the dispatcher, got %s", untouched.NextTriggerTime)
+	}
+}
+
+// snoozingRepository snoozes every reminder of a page right after it was read,
+// like a request racing with RescheduleAllReminders.
+type snoozingRepository struct {
+	repository.ReminderRepository
+	db          *gorm.DB
+	snoozeUntil time.Time
+}
+
+func (r *snoozingRepository) FindPage(afterID int64, limit int) ([]models.Reminder, error) {
+	reminders, err := r.ReminderRepository.FindPage(afterID, limit)
+	for _, reminder := range reminders {
+		r.db.Model(&reminder).Updates(map[string]interface{}{
+			"snoozed_until":     r.snoozeUntil,
+			"next_trigger_time": r.snoozeUntil,
+		})
+	}
+	return reminders, err
+}
+
+func TestReminderService_RescheduleAllReminders_KeepsConcurrentChanges(t *testing.T) {
+	env := setupTestEnv(t, DispatchConfig{})
+	reminder := env.createDueReminder(t, time.Now().Add(240*time.Hour).Truncate(time.Minute))
+	snoozeUntil := time.Now().Add(48 * time.Hour).Truncate(time.Minute).UTC()
+	env.reminderService.reminders = &snoozingRepository{
+		ReminderRepository: env.reminderService.reminders,
+		db:                 env.db,
+		snoozeUntil:        snoozeUntil,
+	}
+
+	changed, err := env.reminderService.RescheduleAllReminders()
+	if err != nil {
+		t.Fatalf("RescheduleAllReminders failed: %v", err)
+	}
+	if changed != 0 {
+		t.Errorf("Expected no rescheduled reminders, got %d", changed)
+	}
+
+	var snoozed models.Reminder
+	env.db.First(&snoozed, reminder.ID)
+	if !snoozed.NextTriggerTime.Equal(snoozeUntil) || snoozed.SnoozedUntil == nil {
+		t.Errorf("Expected the concurrent snooze to be kept, got %s", snoozed.NextTriggerTime)
+	}
+}
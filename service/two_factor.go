@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"plant-reminder/dto"
+	"plant-reminder/models"
+	"plant-reminder/repository"
+	"plant-reminder/utils"
+	"time"
+)
+
+const (
+	// totpIssuer names the service next to the account in authenticator apps.
+	totpIssuer        = "Plantie"
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication has not been set up")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired login challenge")
+)
+
+// EnrollTwoFactor creates a new TOTP secret for the user. Two-factor authentication
+// is only turned on once ConfirmTwoFactor sees a code generated from it.
+func (s *UserService) EnrollTwoFactor(userID int64) (*dto.TwoFactorEnrollResponse, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret := utils.NewTOTPSecret()
+	if err := s.users.SetTOTPSecret(userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &dto.TwoFactorEnrollResponse{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor turns two-factor authentication on after checking a code from
+// the enrolled secret. It returns recovery codes, which are not stored in plain text
+// and can't be shown again.
+func (s *UserService) ConfirmTwoFactor(userID int64, request *dto.TwoFactorConfirmRequest) (*dto.RecoveryCodesResponse, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, request.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	recoveryCodes := make([]models.RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		codes[i] = utils.NewRecoveryCode()
+		recoveryCodes[i] = models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(utils.NormalizeRecoveryCode(codes[i])),
+		}
+	}
+	if err := s.users.EnableTOTP(userID, step, recoveryCodes); err != nil {
+		return nil, err
+	}
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking the password.
+func (s *UserService) DisableTwoFactor(userID int64, request *dto.TwoFactorDisableRequest) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckPassword(user.Password, request.Password); err != nil {
+		return ErrWrongPassword
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	return s.users.DisableTOTP(userID)
+}
+
+// ResetTwoFactor turns two-factor authentication off without checking the password,
+// for users who lost their authenticator and recovery codes.
+func (s *UserService) ResetTwoFactor(userID int64) error {
+	if _, err := s.users.FindByID(userID); err != nil {
+		return err
+	}
+	return s.users.DisableTOTP(userID)
+}
+
+// VerifyTwoFactor completes a login started by VerifyUser with a code from the
+// authenticator app or a recovery code. Either can be used only once.
+func (s *UserService) VerifyTwoFactor(request *dto.TwoFactorLoginRequest) (*dto.AuthResponse, error) {
+	userID, tokenVersion, err := utils.ParseChallengeToken(request.ChallengeToken)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.users.FindByID(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidChallenge
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled || user.TokenVersion != tokenVersion {
+		return nil, ErrInvalidChallenge
+	}
+	if err := checkLocked(user); err != nil {
+		return nil, err
+	}
+
+	err = s.checkSecondFactor(user, request.Code)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if err := s.recordFailedLogin(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.login(user)
+}
+
+func (s *UserService) checkSecondFactor(user *models.User, code string) error {
+	if step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now()); ok {
+		used, err := s.users.UseTOTPStep(user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	used, err := s.users.UseRecoveryCode(user.ID, utils.HashToken(utils.NormalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	fmt.Printf("user %d logged in with a recovery code\n", user.ID)
+	return nil
+}
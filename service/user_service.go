@@ -2,29 +2,127 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"net/url"
 	"plant-reminder/dto"
 	"plant-reminder/models"
+	"plant-reminder/repository"
 	"plant-reminder/utils"
 	"time"
-
-	"gorm.io/gorm"
 )
 
 type UserService struct {
-	db *gorm.DB
+	reminderService *ReminderService
+	mailer          utils.Mailer
+	users           repository.UserRepository
+	config          AccountConfig
+}
+
+// AccountConfig configures the emails sent for email verification and account
+// recovery. The URLs are app pages the emails link to, with the token appended as
+// the token query parameter. If a URL is empty, the email contains the bare token.
+type AccountConfig struct {
+	// PasswordResetTTL is how long a password reset token stays valid.
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	// EmailVerificationTTL is how long an email verification token stays valid.
+	EmailVerificationTTL time.Duration
+	EmailVerificationURL string
+
+	// LockoutThreshold is the number of failed logins after which the account is
+	// locked for LockoutDuration. Every further failure doubles the lock, up to
+	// LockoutMaxDuration.
+	LockoutThreshold   int
+	LockoutDuration    time.Duration
+	LockoutMaxDuration time.Duration
+
+	// OIDCProviders are the OpenID Connect providers users can log in with, by name.
+	OIDCProviders map[string]OIDCProvider
+}
+
+const (
+	defaultPasswordResetTTL     = time.Hour
+	defaultEmailVerificationTTL = 48 * time.Hour
+	defaultLockoutThreshold     = 5
+	defaultLockoutDuration      = time.Minute
+	defaultLockoutMaxDuration   = time.Hour
+)
+
+// withDefaults fills in unset fields.
+func (c AccountConfig) withDefaults() AccountConfig {
+	if c.PasswordResetTTL <= 0 {
+		c.PasswordResetTTL = defaultPasswordResetTTL
+	}
+	if c.EmailVerificationTTL <= 0 {
+		c.EmailVerificationTTL = defaultEmailVerificationTTL
+	}
+	if c.LockoutThreshold <= 0 {
+		c.LockoutThreshold = defaultLockoutThreshold
+	}
+	if c.LockoutDuration <= 0 {
+		c.LockoutDuration = defaultLockoutDuration
+	}
+	if c.LockoutMaxDuration < c.LockoutDuration {
+		c.LockoutMaxDuration = max(defaultLockoutMaxDuration, c.LockoutDuration)
+	}
+	return c
 }
 
 type UserServiceInterface interface {
 	CreateUser(userRequest *dto.UserCreateRequest) (*dto.AuthResponse, error)
-	VerifyUser(email, password string) (*dto.AuthResponse, error)
-	SetPushToken(userID, token string) error
+	VerifyUser(email, password string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error)
+	SetPushToken(userID int64, request *dto.PushTokenRequest) error
+	GetDevices(userID int64) ([]dto.DeviceResponse, error)
+	DeleteDevice(userID int64, deviceID int64) error
 	DeleteUser(userID int64) error
 	GetUser(userID int64) (*dto.UserResponse, error)
+	UpdateProfile(userID int64, request *dto.UserUpdateRequest) (*dto.UserResponse, error)
+	RefreshTokens(refreshToken string) (*dto.TokenResponse, error)
+	Logout(userID int64, refreshToken string) error
+	LogoutAll(userID int64) error
+	ChangePassword(userID int64, request *dto.ChangePasswordRequest) (*dto.TokenResponse, error)
+	RequestPasswordReset(request *dto.ForgotPasswordRequest) error
+	ResetPassword(request *dto.ResetPasswordRequest) error
+	VerifyEmail(request *dto.VerifyEmailRequest) error
+	ResendVerification(userID int64) error
+	VerifyTwoFactor(request *dto.TwoFactorLoginRequest) (*dto.AuthResponse, error)
+	EnrollTwoFactor(userID int64) (*dto.TwoFactorEnrollResponse, error)
+	ConfirmTwoFactor(userID int64, request *dto.TwoFactorConfirmRequest) (*dto.RecoveryCodesResponse, error)
+	DisableTwoFactor(userID int64, request *dto.TwoFactorDisableRequest) error
+	CreateAPIKey(userID int64, request *dto.APIKeyCreateRequest) (*dto.APIKeyCreatedResponse, error)
+	GetAPIKeys(userID int64) ([]dto.APIKeyResponse, error)
+	DeleteAPIKey(userID int64, keyID int64) error
+	LoginWithProvider(provider string, request *dto.OIDCLoginRequest) (*dto.AuthResponse, *dto.TwoFactorChallenge, error)
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrWrongPassword       = errors.New("wrong password")
+	ErrInvalidResetToken   = errors.New("invalid or expired reset token")
+
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrEmailNotVerified         = errors.New("email is not verified")
+
+	ErrDeviceNotFound = errors.New("device not found")
+)
+
+// AccountLockedError is returned for logins to an account that is locked after too
+// many failed attempts.
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "too many failed logins, the account is temporarily locked"
 }
 
-func NewUserService(db *gorm.DB) *UserService {
+func NewUserService(rs *ReminderService, mailer utils.Mailer, users repository.UserRepository, config AccountConfig) *UserService {
 	return &UserService{
-		db: db,
+		reminderService: rs,
+		mailer:          mailer,
+		users:           users,
+		config:          config.withDefaults(),
 	}
 }
 
@@ -42,17 +140,16 @@ func (s *UserService) CreateUser(userRequest *dto.UserCreateRequest) (*dto.AuthR
 	user.CreationDate = time.Now()
 	user.Password = hashedPassword
 
-	result := s.db.Create(user)
-	if result.Error != nil {
+	if err := s.users.Create(user); err != nil {
 		return nil, errors.New("error while writing to database")
 	}
 
-	accessToken, err := utils.SignPayload(user.ID)
-	if err != nil {
-		return nil, err
+	// The account works without verification; the user can ask for a new email.
+	if err := s.sendVerification(user); err != nil {
+		fmt.Printf("failed to send verification email to user %d: %v\n", user.ID, err)
 	}
 
-	refreshToken, err := utils.SignRefreshToken(user.ID)
+	tokens, err := s.startSession(user)
 	if err != nil {
 		return nil, err
 	}
@@ -60,101 +157,485 @@ func (s *UserService) CreateUser(userRequest *dto.UserCreateRequest) (*dto.AuthR
 	userResponse := (&dto.UserResponse{}).FromModel(user)
 	authResponse := &dto.AuthResponse{
 		User:         *userResponse,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}
 
 	return authResponse, nil
 }
 
-func (s *UserService) VerifyUser(email string, password string) (*dto.AuthResponse, error) {
-	var user models.User
-	result := s.db.Where("email = ?", email).First(&user)
-	if result.Error != nil {
-		return nil, result.Error
+// VerifyUser checks the credentials and starts a session. For users with two-factor
+// authentication it returns a challenge instead, to be completed with
+// VerifyTwoFactor.
+func (s *UserService) VerifyUser(email string, password string) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Locked accounts don't even get to the expensive password check.
+	if err := checkLocked(user); err != nil {
+		return nil, nil, err
 	}
 	if err := utils.CheckPassword(user.Password, password); err != nil {
-		return nil, errors.New("wrong credentials")
+		if err := s.recordFailedLogin(user.ID); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, errors.New("wrong credentials")
 	}
 
-	accessToken, err := utils.SignPayload(user.ID)
-	if err != nil {
-		return nil, err
+	return s.completeLogin(user)
+}
+
+// completeLogin starts a session for a user who proved their first factor, or
+// returns a challenge if they have to enter a second one.
+func (s *UserService) completeLogin(user *models.User) (*dto.AuthResponse, *dto.TwoFactorChallenge, error) {
+	if user.TOTPEnabled {
+		challengeToken, err := utils.SignChallengeToken(user.ID, user.TokenVersion)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &dto.TwoFactorChallenge{TwoFactorRequired: true, ChallengeToken: challengeToken}, nil
+	}
+
+	authResponse, err := s.login(user)
+	return authResponse, nil, err
+}
+
+// login starts a session for a user whose credentials were checked.
+func (s *UserService) login(user *models.User) (*dto.AuthResponse, error) {
+	if user.FailedLogins != 0 || user.LockedUntil != nil {
+		if err := s.users.ResetFailedLogins(user.ID); err != nil {
+			return nil, err
+		}
 	}
 
-	refreshToken, err := utils.SignRefreshToken(user.ID)
+	tokens, err := s.startSession(user)
 	if err != nil {
 		return nil, err
 	}
 
-	userResponse := (&dto.UserResponse{}).FromModel(&user)
+	userResponse := (&dto.UserResponse{}).FromModel(user)
 	authResponse := &dto.AuthResponse{
 		User:         *userResponse,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
 	}
 
 	return authResponse, nil
 }
 
 func (s *UserService) GetUser(id int64) (*dto.UserResponse, error) {
-	var user models.User
-	result := s.db.Where("id = ?", id).First(&user)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	user, err := s.users.FindByID(id)
+	if errors.Is(err, repository.ErrNotFound) {
 		return nil, nil
 	}
-	if result.Error != nil {
-		return nil, result.Error
+	if err != nil {
+		return nil, err
 	}
 	user.Password = ""
 
-	userResponse := (&dto.UserResponse{}).FromModel(&user)
+	userResponse := (&dto.UserResponse{}).FromModel(user)
 	return userResponse, nil
 }
 
+func (s *UserService) GetUserByEmail(email string) (*dto.UserResponse, error) {
+	user, err := s.users.FindByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&dto.UserResponse{}).FromModel(user), nil
+}
+
+// SetPassword replaces the user's password without checking the current one, signs
+// the user out everywhere and unlocks the account.
+func (s *UserService) SetPassword(userID int64, password string) error {
+	if _, err := s.users.FindByID(userID); err != nil {
+		return err
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := s.users.Update(&models.User{ID: userID, Password: hashedPassword}); err != nil {
+		return err
+	}
+	if err := s.users.RevokeTokens(userID); err != nil {
+		return err
+	}
+	// A new password also lifts a lock from guessing the old one.
+	return s.users.ResetFailedLogins(userID)
+}
+
+// ChangePassword replaces the password after checking the current one. All sessions
+// are revoked; the returned tokens start a new one for the caller.
+func (s *UserService) ChangePassword(userID int64, request *dto.ChangePasswordRequest) (*dto.TokenResponse, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := utils.CheckPassword(user.Password, request.CurrentPassword); err != nil {
+		return nil, ErrWrongPassword
+	}
+
+	if err := s.SetPassword(userID, request.NewPassword); err != nil {
+		return nil, err
+	}
+
+	// SetPassword incremented the token version.
+	user, err = s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.startSession(user)
+}
+
+// RequestPasswordReset emails a single-use reset token to the user. Unknown emails
+// are not an error, so the response doesn't reveal which emails have accounts.
+func (s *UserService) RequestPasswordReset(request *dto.ForgotPasswordRequest) error {
+	user, err := s.users.FindByEmail(request.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token := utils.NewSecretToken()
+	reset := &models.PasswordReset{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.config.PasswordResetTTL),
+	}
+	if err := s.users.CreatePasswordReset(reset); err != nil {
+		return err
+	}
+
+	// A delivery failure is not returned either, as only existing users get here.
+	if err := s.mailer.Send(s.passwordResetEmail(user, token, reset.ExpiresAt)); err != nil {
+		fmt.Printf("failed to send password reset email to user %d: %v\n", user.ID, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a token from RequestPasswordReset and
+// signs the user out everywhere.
+func (s *UserService) ResetPassword(request *dto.ResetPasswordRequest) error {
+	reset, err := s.users.UsePasswordReset(utils.HashToken(request.Token), time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.SetPassword(reset.UserID, request.NewPassword)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidResetToken
+	}
+	return err
+}
+
+func (s *UserService) passwordResetEmail(user *models.User, token string, expiresAt time.Time) utils.Email {
+	return utils.Email{
+		To:      user.Email,
+		Subject: "Reset your Plantie password",
+		Body: fmt.Sprintf("Hi,\n\nSomeone asked to reset the password of your Plantie account. "+
+			"Use this to choose a new one until %s:\n\n%s\n\n"+
+			"If it wasn't you, you can ignore this email; your password stays the same.\n",
+			formatExpiry(user, expiresAt), tokenLink(s.config.PasswordResetURL, token)),
+	}
+}
+
+// VerifyEmail marks the owner of a token from a verification email as verified.
+func (s *UserService) VerifyEmail(request *dto.VerifyEmailRequest) error {
+	_, err := s.users.UseEmailVerification(utils.HashToken(request.Token), time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrInvalidVerificationToken
+	}
+	return err
+}
+
+// ResendVerification sends a new verification email. Earlier ones stop working.
+func (s *UserService) ResendVerification(userID int64) error {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+	return s.sendVerification(user)
+}
+
+func (s *UserService) sendVerification(user *models.User) error {
+	token := utils.NewSecretToken()
+	verification := &models.EmailVerification{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.config.EmailVerificationTTL),
+	}
+	if err := s.users.CreateEmailVerification(verification); err != nil {
+		return err
+	}
+
+	return s.mailer.Send(utils.Email{
+		To:      user.Email,
+		Subject: "Confirm your email for Plantie",
+		Body: fmt.Sprintf("Hi,\n\nPlease confirm that this is the email address of your Plantie account "+
+			"until %s:\n\n%s\n\nIf you didn't sign up for Plantie, you can ignore this email.\n",
+			formatExpiry(user, verification.ExpiresAt), tokenLink(s.config.EmailVerificationURL, token)),
+	})
+}
+
+// tokenLink appends the token to the app page at pageURL, or returns the bare token
+// if there is no page.
+func tokenLink(pageURL string, token string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil || pageURL == "" {
+		return token
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// formatExpiry formats the expiry of a mailed token in the user's time zone.
+func formatExpiry(user *models.User, expiresAt time.Time) string {
+	return expiresAt.In(utils.LoadLocation(user.TimeZone)).Format("Jan 2, 15:04 MST")
+}
+
 func (s *UserService) UpdateUser(user *models.User) error {
 	if user.ID == 0 {
 		return errors.New("user ID must be set")
 	}
 
-	updates := map[string]interface{}{}
-	if user.Email != "" {
-		updates["email"] = user.Email
+	// Only the profile fields may change here; empty ones are left untouched.
+	return s.users.Update(&models.User{
+		ID:       user.ID,
+		Email:    user.Email,
+		Name:     user.Name,
+		TimeZone: user.TimeZone,
+	})
+}
+
+// UpdateProfile updates the user's name and time zone. Changing the time zone
+// reschedules all of the user's reminders.
+func (s *UserService) UpdateProfile(userID int64, request *dto.UserUpdateRequest) (*dto.UserResponse, error) {
+	existing, err := s.users.FindByID(userID)
+	if err != nil {
+		return nil, err
 	}
-	if user.Name != "" {
-		updates["name"] = user.Name
+
+	update := &models.User{ID: userID, Name: request.Name, TimeZone: request.TimeZone}
+	if err := s.UpdateUser(update); err != nil {
+		return nil, err
 	}
-	if user.PushToken != "" {
-		updates["push_token"] = user.PushToken
+
+	if request.TimeZone != "" && request.TimeZone != existing.TimeZone {
+		if err := s.reminderService.RescheduleUserReminders(userID); err != nil {
+			return nil, err
+		}
 	}
 
-	result := s.db.Model(user).Updates(updates)
-	return result.Error
+	return s.GetUser(userID)
 }
 
-func (s *UserService) SetPushToken(userID string, token string) error {
-	result := s.db.Model(&models.User{}).
-		Where("id = ?", userID).
-		Update("push_token", token)
-	return result.Error
+// SetPushToken registers the device behind a push token, or refreshes it if the
+// token is already known. A token seen under another account moves to this user.
+func (s *UserService) SetPushToken(userID int64, request *dto.PushTokenRequest) error {
+	device := request.ToModel(userID)
+	device.LastSeenAt = time.Now()
+
+	return s.users.UpsertDevice(device)
+}
+
+func (s *UserService) GetDevices(userID int64) ([]dto.DeviceResponse, error) {
+	devices, err := s.users.FindDevices(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.FromDevicesModel(devices), nil
+}
+
+func (s *UserService) DeleteDevice(userID int64, deviceID int64) error {
+	device, err := s.users.FindDevice(deviceID, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrDeviceNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.users.DeleteDevice(device)
 }
 
 func (s *UserService) DeleteUser(userID int64) error {
-	var user models.User
-	result := s.db.Where("id = ?", userID).First(&user)
-	if result.Error != nil {
-		return result.Error
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return err
 	}
 
-	result = s.db.Delete(&user)
-	return result.Error
+	return s.users.Delete(user)
 }
 
 func (s *UserService) userExists(email string) (bool, error) {
-	var user models.User
-	result := s.db.Where("email = ?", email).First(&user)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	_, err := s.users.FindByEmail(email)
+	if errors.Is(err, repository.ErrNotFound) {
 		return false, nil
 	}
-	return result.Error == nil, result.Error
+	return err == nil, err
+}
+
+func checkLocked(user *models.User) error {
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return &AccountLockedError{Until: *user.LockedUntil}
+	}
+	return nil
+}
+
+// recordFailedLogin counts a wrong password or second factor and locks the account
+// once there are too many.
+func (s *UserService) recordFailedLogin(userID int64) error {
+	failures, err := s.users.RecordFailedLogin(userID)
+	if err != nil || failures < s.config.LockoutThreshold {
+		return err
+	}
+
+	lock := s.config.LockoutDuration
+	for i := s.config.LockoutThreshold; i < failures && lock < s.config.LockoutMaxDuration; i++ {
+		lock *= 2
+	}
+	lock = min(lock, s.config.LockoutMaxDuration)
+
+	fmt.Printf("user %d locked for %s after %d failed logins\n", userID, lock, failures)
+	return s.users.LockUser(userID, time.Now().Add(lock))
+}
+
+// startSession signs an access token and starts a new refresh session family.
+func (s *UserService) startSession(user *models.User) (*dto.TokenResponse, error) {
+	if err := s.users.DeleteExpiredSessions(user.ID, time.Now()); err != nil {
+		return nil, err
+	}
+
+	session := newRefreshSession(user.ID, utils.NewTokenID())
+	if err := s.users.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return signTokens(user, session)
+}
+
+// RefreshTokens exchanges a refresh token for a new pair. Every refresh token can
+// be used once. Presenting one that was already used means it leaked, so the whole
+// session family is revoked and the legitimate client has to log in again.
+func (s *UserService) RefreshTokens(refreshToken string) (*dto.TokenResponse, error) {
+	session, err := s.findSession(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if session.ReplacedBy != "" {
+		return nil, s.revokeReusedSession(session)
+	}
+
+	user, err := s.users.FindByID(session.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := newRefreshSession(user.ID, session.FamilyID)
+	rotated, err := s.users.RotateSession(session, next)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		return nil, s.revokeReusedSession(session)
+	}
+	return signTokens(user, next)
+}
+
+// Logout revokes the session family of the refresh token. Access tokens already
+// issued stay valid until they expire.
+func (s *UserService) Logout(userID int64, refreshToken string) error {
+	session, err := s.findSession(refreshToken)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return ErrInvalidRefreshToken
+	}
+	return s.users.RevokeSessionFamily(session.FamilyID)
+}
+
+// LogoutAll revokes every refresh session and access token of the user.
+func (s *UserService) LogoutAll(userID int64) error {
+	return s.users.RevokeTokens(userID)
+}
+
+// TokenVersion returns the user's current token version. Access tokens signed with
+// another version were revoked.
+func (s *UserService) TokenVersion(userID int64) (int, error) {
+	user, err := s.users.FindByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	return user.TokenVersion, nil
+}
+
+func (s *UserService) findSession(refreshToken string) (*models.RefreshSession, error) {
+	userID, jti, err := utils.ParseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	session, err := s.users.FindSession(jti)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, ErrInvalidRefreshToken
+	}
+	return session, nil
+}
+
+func (s *UserService) revokeReusedSession(session *models.RefreshSession) error {
+	fmt.Printf("refresh token reuse detected for user %d, revoking session family\n", session.UserID)
+	if err := s.users.RevokeSessionFamily(session.FamilyID); err != nil {
+		return err
+	}
+	return ErrInvalidRefreshToken
+}
+
+func newRefreshSession(userID int64, familyID string) *models.RefreshSession {
+	return &models.RefreshSession{
+		UserID:    userID,
+		FamilyID:  familyID,
+		JTI:       utils.NewTokenID(),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL()),
+	}
+}
+
+func signTokens(user *models.User, session *models.RefreshSession) (*dto.TokenResponse, error) {
+	accessToken, err := utils.SignPayload(user.ID, user.TokenVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.SignRefreshToken(user.ID, session.JTI)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
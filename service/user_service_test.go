@@ -0,0 +1,574 @@
+package service
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"plant-reminder/dto"
+	"plant-reminder/models"
+	"plant-reminder/oidc"
+	"plant-reminder/oidc/oidctest"
+	"plant-reminder/repository"
+	"plant-reminder/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func setupUserService(t *testing.T) (*UserService, *testEnv, *utils.RecordingMailer) {
+	t.Helper()
+	utils.InitTokens("test-key", time.Hour, 24*time.Hour)
+	utils.SetPasswordCost(4)
+
+	env := setupTestEnv(t, DispatchConfig{})
+	mailer := utils.NewRecordingMailer()
+	accountConfig := AccountConfig{
+		PasswordResetURL:     "https://app.example.com/reset",
+		EmailVerificationURL: "https://app.example.com/verify",
+	}
+	return NewUserService(env.reminderService, mailer, repository.NewUserRepository(env.db), accountConfig), env, mailer
+}
+
+func TestUserService_RefreshTokens_RotatesAndDetectsReuse(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+	auth, err := userService.CreateUser(&dto.UserCreateRequest{Email: "new@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	rotated, err := userService.RefreshTokens(auth.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokens failed: %v", err)
+	}
+	if rotated.RefreshToken == auth.RefreshToken {
+		t.Error("Expected a new refresh token")
+	}
+
+	if _, err := userService.RefreshTokens(auth.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("Expected reuse of the old token to fail, got %v", err)
+	}
+	if _, err := userService.RefreshTokens(rotated.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected reuse to revoke the whole session family, got %v", err)
+	}
+
+	var active int64
+	env.db.Model(&models.RefreshSession{}).Where("revoked_at IS NULL").Count(&active)
+	if active != 0 {
+		t.Errorf("Expected no active sessions, got %d", active)
+	}
+}
+
+func TestUserService_RefreshTokens_SwitchToAsymmetricKeys(t *testing.T) {
+	userService, _, _ := setupUserService(t)
+	legacy, err := userService.CreateUser(&dto.UserCreateRequest{Email: "new@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	key, err := utils.GenerateTokenKey(utils.KeyTypeEd25519)
+	if err != nil {
+		t.Fatalf("GenerateTokenKey failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "k1.pem"), key, 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	if err := utils.LoadTokenKeys(dir, ""); err != nil {
+		t.Fatalf("LoadTokenKeys failed: %v", err)
+	}
+
+	// With the HS256 secret still set, older tokens keep working.
+	rotated, err := userService.RefreshTokens(legacy.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected the HS256 refresh token to be accepted, got %v", err)
+	}
+	token, err := utils.VerifyPayload(rotated.AccessToken)
+	if err != nil {
+		t.Fatalf("VerifyPayload failed: %v", err)
+	}
+	if token.Method.Alg() != "EdDSA" || token.Header["kid"] != "k1" {
+		t.Errorf("Expected an EdDSA token signed with k1, got %s %v", token.Method.Alg(), token.Header["kid"])
+	}
+
+	// Without it, only tokens of the key directory are accepted.
+	utils.InitTokens("", time.Hour, 24*time.Hour)
+	if err := utils.LoadTokenKeys(dir, ""); err != nil {
+		t.Fatalf("LoadTokenKeys failed: %v", err)
+	}
+	if _, err := utils.VerifyPayload(legacy.AccessToken); err == nil {
+		t.Error("Expected the HS256 access token to be rejected")
+	}
+	if _, err := userService.RefreshTokens(rotated.RefreshToken); err != nil {
+		t.Errorf("Expected the new refresh token to work, got %v", err)
+	}
+}
+
+func TestUserService_VerifyPayload_RejectsAlgorithmConfusion(t *testing.T) {
+	setupUserService(t)
+	dir := t.TempDir()
+	key, err := utils.GenerateTokenKey(utils.KeyTypeRSA)
+	if err != nil {
+		t.Fatalf("GenerateTokenKey failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "k1.pem"), key, 0o600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	if err := utils.LoadTokenKeys(dir, ""); err != nil {
+		t.Fatalf("LoadTokenKeys failed: %v", err)
+	}
+	private, err := jwt.ParseRSAPrivateKeyFromPEM(key)
+	if err != nil {
+		t.Fatalf("Failed to parse key: %v", err)
+	}
+	public, err := x509.MarshalPKIXPublicKey(&private.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	claims := jwt.MapClaims{"userID": 1, "exp": time.Now().Add(time.Hour).Unix(), "type": "access"}
+
+	// An HS256 token for k1, with the published public key as the HMAC secret.
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	hmacToken.Header["kid"] = "k1"
+	forged, err := hmacToken.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	if _, err := utils.VerifyPayload(forged); !errors.Is(err, jwt.ErrTokenUnverifiable) {
+		t.Errorf("Expected an HS256 token with a kid to be unverifiable, got %v", err)
+	}
+
+	// An RS256 token without a kid, which would be checked against the HS256 secret.
+	unnamed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(private)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	if _, err := utils.VerifyPayload(unnamed); !errors.Is(err, jwt.ErrTokenUnverifiable) {
+		t.Errorf("Expected an RS256 token without a kid to be unverifiable, got %v", err)
+	}
+}
+
+func TestUserService_LogoutAll_RevokesEverything(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+	if err := userService.SetPassword(env.user.ID, "secret123"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+	phone, _, err := userService.VerifyUser(env.user.Email, "secret123")
+	if err != nil {
+		t.Fatalf("VerifyUser failed: %v", err)
+	}
+	laptop, _, err := userService.VerifyUser(env.user.Email, "secret123")
+	if err != nil {
+		t.Fatalf("VerifyUser failed: %v", err)
+	}
+
+	if err := userService.Logout(env.user.ID, phone.RefreshToken); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+	if _, err := userService.RefreshTokens(phone.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected the logged out session to be revoked, got %v", err)
+	}
+	laptopTokens, err := userService.RefreshTokens(laptop.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected the other session to keep working, got %v", err)
+	}
+
+	before, _ := userService.TokenVersion(env.user.ID)
+	if err := userService.LogoutAll(env.user.ID); err != nil {
+		t.Fatalf("LogoutAll failed: %v", err)
+	}
+	after, _ := userService.TokenVersion(env.user.ID)
+	if after != before+1 {
+		t.Errorf("Expected the token version to go from %d to %d, got %d", before, before+1, after)
+	}
+	if _, err := userService.RefreshTokens(laptopTokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected all sessions to be revoked, got %v", err)
+	}
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+	if err := userService.SetPassword(env.user.ID, "secret123"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+	session, _, err := userService.VerifyUser(env.user.Email, "secret123")
+	if err != nil {
+		t.Fatalf("VerifyUser failed: %v", err)
+	}
+
+	wrong := &dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "changed123"}
+	if _, err := userService.ChangePassword(env.user.ID, wrong); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("Expected ErrWrongPassword, got %v", err)
+	}
+
+	request := &dto.ChangePasswordRequest{CurrentPassword: "secret123", NewPassword: "changed123"}
+	tokens, err := userService.ChangePassword(env.user.ID, request)
+	if err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+	if _, _, err := userService.VerifyUser(env.user.Email, "changed123"); err != nil {
+		t.Errorf("Expected the new password to work, got %v", err)
+	}
+	if _, err := userService.RefreshTokens(session.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected the old session to be revoked, got %v", err)
+	}
+	if _, err := userService.RefreshTokens(tokens.RefreshToken); err != nil {
+		t.Errorf("Expected the returned session to work, got %v", err)
+	}
+}
+
+func TestUserService_VerifyUser_LocksAfterFailedLogins(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+	if err := userService.SetPassword(env.user.ID, "secret123"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+
+	for range defaultLockoutThreshold {
+		if _, _, err := userService.VerifyUser(env.user.Email, "wrong"); err == nil {
+			t.Fatal("Expected a wrong password to fail")
+		}
+	}
+
+	var locked *AccountLockedError
+	_, _, err := userService.VerifyUser(env.user.Email, "secret123")
+	if !errors.As(err, &locked) {
+		t.Fatalf("Expected the account to be locked, got %v", err)
+	}
+	if wait := time.Until(locked.Until); wait <= 0 || wait > defaultLockoutDuration {
+		t.Errorf("Expected a lock of up to %s, got %s", defaultLockoutDuration, wait)
+	}
+
+	// Another failure once the lock ran out doubles it.
+	env.db.Model(&models.User{}).Where("id = ?", env.user.ID).Update("locked_until", time.Now().Add(-time.Second))
+	if _, _, err := userService.VerifyUser(env.user.Email, "wrong"); err == nil {
+		t.Fatal("Expected a wrong password to fail")
+	}
+	_, _, err = userService.VerifyUser(env.user.Email, "secret123")
+	if !errors.As(err, &locked) {
+		t.Fatalf("Expected the account to be locked again, got %v", err)
+	}
+	if wait := time.Until(locked.Until); wait <= defaultLockoutDuration {
+		t.Errorf("Expected a longer lock, got %s", wait)
+	}
+
+	env.db.Model(&models.User{}).Where("id = ?", env.user.ID).Update("locked_until", time.Now().Add(-time.Second))
+	if _, _, err := userService.VerifyUser(env.user.Email, "secret123"); err != nil {
+		t.Fatalf("Expected login after the lock, got %v", err)
+	}
+	user, _ := repository.NewUserRepository(env.db).FindByID(env.user.ID)
+	if user.FailedLogins != 0 || user.LockedUntil != nil {
+		t.Errorf("Expected a successful login to reset the lockout, got %d failures until %v", user.FailedLogins, user.LockedUntil)
+	}
+}
+
+func TestUserService_ResetPassword(t *testing.T) {
+	userService, env, mailer := setupUserService(t)
+	if err := userService.SetPassword(env.user.ID, "secret123"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+	session, _, err := userService.VerifyUser(env.user.Email, "secret123")
+	if err != nil {
+		t.Fatalf("VerifyUser failed: %v", err)
+	}
+
+	if err := userService.RequestPasswordReset(&dto.ForgotPasswordRequest{Email: "nobody@example.com"}); err != nil {
+		t.Fatalf("Expected no error for an unknown email, got %v", err)
+	}
+	if len(mailer.Emails()) != 0 {
+		t.Fatalf("Expected no email for an unknown address, got %d", len(mailer.Emails()))
+	}
+
+	if err := userService.RequestPasswordReset(&dto.ForgotPasswordRequest{Email: env.user.Email}); err != nil {
+		t.Fatalf("RequestPasswordReset failed: %v", err)
+	}
+	emails := mailer.Emails()
+	if len(emails) != 1 || emails[0].To != env.user.Email {
+		t.Fatalf("Expected one email to %s, got %+v", env.user.Email, emails)
+	}
+	token := tokenFromEmail(t, emails[0].Body)
+
+	var stored models.PasswordReset
+	env.db.Where("user_id = ?", env.user.ID).First(&stored)
+	if stored.TokenHash == token || stored.TokenHash != utils.HashToken(token) {
+		t.Errorf("Expected only the hash of the token to be stored, got %q", stored.TokenHash)
+	}
+
+	request := &dto.ResetPasswordRequest{Token: token, NewPassword: "changed123"}
+	if err := userService.ResetPassword(request); err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+	if _, _, err := userService.VerifyUser(env.user.Email, "changed123"); err != nil {
+		t.Errorf("Expected the new password to work, got %v", err)
+	}
+	if _, err := userService.RefreshTokens(session.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("Expected existing sessions to be revoked, got %v", err)
+	}
+	if err := userService.ResetPassword(request); !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("Expected the token to be single-use, got %v", err)
+	}
+}
+
+func TestUserService_ResetPassword_Expired(t *testing.T) {
+	userService, env, mailer := setupUserService(t)
+
+	if err := userService.RequestPasswordReset(&dto.ForgotPasswordRequest{Email: env.user.Email}); err != nil {
+		t.Fatalf("RequestPasswordReset failed: %v", err)
+	}
+	token := tokenFromEmail(t, mailer.Emails()[0].Body)
+	env.db.Model(&models.PasswordReset{}).Where("user_id = ?", env.user.ID).
+		Update("expires_at", time.Now().Add(-time.Minute))
+
+	err := userService.ResetPassword(&dto.ResetPasswordRequest{Token: token, NewPassword: "changed123"})
+	if !errors.Is(err, ErrInvalidResetToken) {
+		t.Errorf("Expected an expired token to be rejected, got %v", err)
+	}
+}
+
+// tokenFromEmail extracts the token from the link in an email.
+func tokenFromEmail(t *testing.T, body string) string {
+	t.Helper()
+	for _, field := range strings.Fields(body) {
+		link, err := url.Parse(field)
+		if err == nil && link.Host == "app.example.com" {
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("No link in email:\n%s", body)
+	return ""
+}
+
+func TestUserService_VerifyEmail(t *testing.T) {
+	userService, _, mailer := setupUserService(t)
+	auth, err := userService.CreateUser(&dto.UserCreateRequest{Email: "new@example.com", Password: "secret123"})
+	if err != nil {
+		t.Fatalf("CreateUser failed: %v", err)
+	}
+	if auth.User.EmailVerified {
+		t.Fatal("Expected a new user to be unverified")
+	}
+	emails := mailer.Emails()
+	if len(emails) != 1 || emails[0].To != "new@example.com" {
+		t.Fatalf("Expected a verification email to new@example.com, got %+v", emails)
+	}
+	first := tokenFromEmail(t, emails[0].Body)
+
+	if err := userService.ResendVerification(auth.User.ID); err != nil {
+		t.Fatalf("ResendVerification failed: %v", err)
+	}
+	second := tokenFromEmail(t, mailer.Emails()[1].Body)
+	if err := userService.VerifyEmail(&dto.VerifyEmailRequest{Token: first}); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Errorf("Expected the earlier token to stop working, got %v", err)
+	}
+
+	if err := userService.VerifyEmail(&dto.VerifyEmailRequest{Token: second}); err != nil {
+		t.Fatalf("VerifyEmail failed: %v", err)
+	}
+	user, _ := userService.GetUser(auth.User.ID)
+	if !user.EmailVerified {
+		t.Error("Expected the email to be verified")
+	}
+	if err := userService.VerifyEmail(&dto.VerifyEmailRequest{Token: second}); !errors.Is(err, ErrInvalidVerificationToken) {
+		t.Errorf("Expected the token to be single-use, got %v", err)
+	}
+	if err := userService.ResendVerification(auth.User.ID); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("Expected ErrEmailAlreadyVerified, got %v", err)
+	}
+}
+
+func TestUserService_TwoFactor(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+	if err := userService.SetPassword(env.user.ID, "secret123"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+
+	enrollment, err := userService.EnrollTwoFactor(env.user.ID)
+	if err != nil {
+		t.Fatalf("EnrollTwoFactor failed: %v", err)
+	}
+	if !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/") {
+		t.Errorf("Unexpected otpauth URI %q", enrollment.OTPAuthURI)
+	}
+
+	// An earlier period's code confirms, so the next login can use the current one.
+	previous, _ := utils.GenerateTOTP(enrollment.Secret, time.Now().Add(-30*time.Second))
+	codes, err := userService.ConfirmTwoFactor(env.user.ID, &dto.TwoFactorConfirmRequest{Code: previous})
+	if err != nil {
+		t.Fatalf("ConfirmTwoFactor failed: %v", err)
+	}
+	if len(codes.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("Expected %d recovery codes, got %d", recoveryCodeCount, len(codes.RecoveryCodes))
+	}
+
+	auth, challenge, err := userService.VerifyUser(env.user.Email, "secret123")
+	if err != nil || auth != nil || challenge == nil {
+		t.Fatalf("Expected a two-factor challenge, got %+v, %+v, %v", auth, challenge, err)
+	}
+
+	current, _ := utils.GenerateTOTP(enrollment.Secret, time.Now())
+	login := &dto.TwoFactorLoginRequest{ChallengeToken: challenge.ChallengeToken, Code: current}
+	if _, err := userService.VerifyTwoFactor(login); err != nil {
+		t.Fatalf("VerifyTwoFactor failed: %v", err)
+	}
+	if _, err := userService.VerifyTwoFactor(login); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("Expected a used code to be rejected, got %v", err)
+	}
+
+	recovery := &dto.TwoFactorLoginRequest{
+		ChallengeToken: challenge.ChallengeToken,
+		Code:           strings.ToLower(codes.RecoveryCodes[0]),
+	}
+	if _, err := userService.VerifyTwoFactor(recovery); err != nil {
+		t.Fatalf("Expected the recovery code to work, got %v", err)
+	}
+	if _, err := userService.VerifyTwoFactor(recovery); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Errorf("Expected a used recovery code to be rejected, got %v", err)
+	}
+
+	if err := userService.DisableTwoFactor(env.user.ID, &dto.TwoFactorDisableRequest{Password: "wrong"}); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Expected ErrWrongPassword, got %v", err)
+	}
+	if err := userService.DisableTwoFactor(env.user.ID, &dto.TwoFactorDisableRequest{Password: "secret123"}); err != nil {
+		t.Fatalf("DisableTwoFactor failed: %v", err)
+	}
+	if auth, _, err := userService.VerifyUser(env.user.Email, "secret123"); err != nil || auth == nil {
+		t.Errorf("Expected a one-step login after disabling, got %v", err)
+	}
+	if _, err := userService.VerifyTwoFactor(recovery); !errors.Is(err, ErrInvalidChallenge) {
+		t.Errorf("Expected pending challenges to stop working, got %v", err)
+	}
+}
+
+func TestUserService_APIKeys(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+
+	request := &dto.APIKeyCreateRequest{Name: "cron", Scopes: []string{"plants:read", "reminders:write", "plants:read"}}
+	created, err := userService.CreateAPIKey(env.user.ID, request)
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+	if !strings.HasPrefix(created.Key, utils.APIKeyPrefix) || !strings.HasPrefix(created.Key, created.Prefix) {
+		t.Errorf("Unexpected key %q with prefix %q", created.Key, created.Prefix)
+	}
+	var stored models.APIKey
+	env.db.First(&stored, created.ID)
+	if stored.KeyHash == created.Key || stored.KeyHash != utils.HashToken(created.Key) {
+		t.Error("Expected only the hash of the key to be stored")
+	}
+
+	userID, scopes, err := userService.AuthenticateAPIKey(created.Key)
+	if err != nil {
+		t.Fatalf("AuthenticateAPIKey failed: %v", err)
+	}
+	if userID != env.user.ID || strings.Join(scopes, " ") != "plants:read reminders:write" {
+		t.Errorf("Expected the owner and deduplicated scopes, got %d %v", userID, scopes)
+	}
+
+	keys, err := userService.GetAPIKeys(env.user.ID)
+	if err != nil {
+		t.Fatalf("GetAPIKeys failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0].LastUsedAt == nil {
+		t.Errorf("Expected the key with its last use, got %+v", keys)
+	}
+
+	if err := userService.DeleteAPIKey(env.user.ID+1, created.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("Expected other users not to delete the key, got %v", err)
+	}
+	if err := userService.DeleteAPIKey(env.user.ID, created.ID); err != nil {
+		t.Fatalf("DeleteAPIKey failed: %v", err)
+	}
+	if _, _, err := userService.AuthenticateAPIKey(created.Key); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("Expected a deleted key to be rejected, got %v", err)
+	}
+}
+
+func TestUserService_LoginWithProvider(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+	issuer := oidctest.NewIssuer("plantie", "secret")
+	defer issuer.Close()
+	userService.config.OIDCProviders = map[string]OIDCProvider{
+		"test": oidc.NewProvider(oidc.Config{Name: "test", Issuer: issuer.URL, ClientID: "plantie", ClientSecret: "secret"}, nil),
+	}
+	login := func(claims jwt.MapClaims) (*dto.AuthResponse, error) {
+		claims["nonce"] = "n-1"
+		code := issuer.Authorize("app:/callback", "verifier", claims)
+		request := &dto.OIDCLoginRequest{Code: code, RedirectURI: "app:/callback", CodeVerifier: "verifier", Nonce: "n-1", TimeZone: "Europe/Berlin"}
+		auth, _, err := userService.LoginWithProvider("test", request)
+		return auth, err
+	}
+
+	if _, _, err := userService.LoginWithProvider("other", &dto.OIDCLoginRequest{}); !errors.Is(err, ErrUnknownProvider) {
+		t.Errorf("Expected ErrUnknownProvider, got %v", err)
+	}
+	if _, err := login(jwt.MapClaims{"sub": "1", "email": "new@example.com", "email_verified": false}); !errors.Is(err, ErrProviderEmailNotVerified) {
+		t.Errorf("Expected an unverified email to be rejected, got %v", err)
+	}
+
+	created, err := login(jwt.MapClaims{"sub": "1", "email": "new@example.com", "email_verified": true, "name": "New"})
+	if err != nil {
+		t.Fatalf("LoginWithProvider failed: %v", err)
+	}
+	if created.User.Email != "new@example.com" || created.User.Name != "New" || created.User.TimeZone != "Europe/Berlin" || !created.User.EmailVerified {
+		t.Errorf("Expected a new verified user, got %+v", created.User)
+	}
+
+	// The subject identifies the user from now on, even once the email changes.
+	again, err := login(jwt.MapClaims{"sub": "1", "email": "changed@example.com", "email_verified": false})
+	if err != nil {
+		t.Fatalf("LoginWithProvider failed: %v", err)
+	}
+	if again.User.ID != created.User.ID {
+		t.Errorf("Expected user %d, got %d", created.User.ID, again.User.ID)
+	}
+
+	if err := userService.DeleteUser(created.User.ID); err != nil {
+		t.Fatalf("DeleteUser failed: %v", err)
+	}
+	var identities int64
+	env.db.Model(&models.UserIdentity{}).Where("user_id = ?", created.User.ID).Count(&identities)
+	if identities != 0 {
+		t.Errorf("Expected the identities to be deleted with the user, got %d", identities)
+	}
+}
+
+func TestUserService_LoginWithProvider_LinksByEmail(t *testing.T) {
+	userService, env, _ := setupUserService(t)
+	issuer := oidctest.NewIssuer("plantie", "")
+	defer issuer.Close()
+	userService.config.OIDCProviders = map[string]OIDCProvider{
+		"test": oidc.NewProvider(oidc.Config{Name: "test", Issuer: issuer.URL, ClientID: "plantie"}, nil),
+	}
+	if err := userService.SetPassword(env.user.ID, "secret123"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+	var before models.User
+	env.db.First(&before, env.user.ID)
+
+	code := issuer.Authorize("app:/callback", "", jwt.MapClaims{"sub": "1", "email": env.user.Email, "email_verified": "true", "nonce": "n-1"})
+	auth, _, err := userService.LoginWithProvider("test", &dto.OIDCLoginRequest{Code: code, RedirectURI: "app:/callback", Nonce: "n-1"})
+	if err != nil {
+		t.Fatalf("LoginWithProvider failed: %v", err)
+	}
+	if auth.User.ID != env.user.ID || !auth.User.EmailVerified {
+		t.Errorf("Expected the existing user to be linked and verified, got %+v", auth.User)
+	}
+	// The local account never proved it owns the address, so its password must
+	// not let anyone in anymore.
+	if _, _, err := userService.VerifyUser(env.user.Email, "secret123"); err == nil {
+		t.Error("Expected the old password to be replaced")
+	}
+	var linked models.User
+	env.db.First(&linked, env.user.ID)
+	if linked.TokenVersion <= before.TokenVersion {
+		t.Errorf("Expected the tokens of the old password to be revoked, got version %d", linked.TokenVersion)
+	}
+	var identities int64
+	env.db.Model(&models.UserIdentity{}).Where("user_id = ?", env.user.ID).Count(&identities)
+	if identities != 1 {
+		t.Errorf("Expected one linked identity, got %d", identities)
+	}
+}
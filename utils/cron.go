@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const maxCronExpressionLength = 100
+
+var cronParser = cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+
+// ParseCronExpression parses a standard 5-field cron expression (minute, hour,
+// day of month, month, day of week). Time zone prefixes are rejected because
+// reminders always run in their owner's time zone.
+func ParseCronExpression(expression string) (cron.Schedule, error) {
+	if len(expression) > maxCronExpressionLength {
+		return nil, fmt.Errorf("cronExpression must be at most %d characters", maxCronExpressionLength)
+	}
+	if strings.HasPrefix(expression, "TZ=") || strings.HasPrefix(expression, "CRON_TZ=") {
+		return nil, errors.New("cronExpression must not contain a time zone")
+	}
+
+	schedule, err := cronParser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cronExpression: %w", err)
+	}
+	if schedule.Next(time.Now()).IsZero() {
+		return nil, errors.New("cronExpression never fires")
+	}
+	return schedule, nil
+}
@@ -1,5 +1,11 @@
 package utils
 
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
 func Map[T, V any](input []T, fn func(T) V) []V {
 	result := make([]V, len(input))
 	for i, t := range input {
@@ -7,3 +13,22 @@ func Map[T, V any](input []T, fn func(T) V) []V {
 	}
 	return result
 }
+
+// LoadLocation resolves an IANA time zone name, falling back to UTC for
+// empty or unknown names.
+func LoadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// RetryAfter formats a wait as the value of a Retry-After header, in whole seconds
+// rounded up.
+func RetryAfter(wait time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(wait.Seconds())))
+}
@@ -0,0 +1,196 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	KeyTypeRSA     = "rsa"
+	KeyTypeEd25519 = "ed25519"
+
+	// rsaKeyBits is the size of generated RSA keys.
+	rsaKeyBits = 3072
+)
+
+// jwtKey is an asymmetric key from the key directory. Keys whose private part was
+// removed only verify tokens.
+type jwtKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// JSONWebKey is the public part of a signing key as published in a JWKS.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519 keys
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// LoadTokenKeys loads the RSA and Ed25519 keys of dir, one PEM file per key named
+// <kid>.pem. Tokens are then signed with the private key signingKeyID, or if it is
+// empty with the private key whose kid sorts last, and verified with any key of the
+// directory. A secret set with InitTokens still verifies HS256 tokens, so they keep
+// working while users switch over, and signs tokens while dir has no private key.
+func LoadTokenKeys(dir string, signingKeyID string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*jwtKey)
+	for _, path := range paths {
+		key, err := readJWTKey(path)
+		if err != nil {
+			return fmt.Errorf("failed to load token key %s: %w", path, err)
+		}
+		keys[key.id] = key
+	}
+
+	if signingKeyID == "" {
+		for _, key := range keys {
+			if key.private != nil && key.id > signingKeyID {
+				signingKeyID = key.id
+			}
+		}
+	}
+	if signingKeyID == "" {
+		// Until the first key is generated, the HS256 secret goes on signing.
+		if len(tokenSettings.key) == 0 {
+			return fmt.Errorf("no private key in %s", dir)
+		}
+		tokenSettings.keys = keys
+		return nil
+	}
+	signing, ok := keys[signingKeyID]
+	if !ok || signing.private == nil {
+		return fmt.Errorf("no private key %s in %s", signingKeyID, dir)
+	}
+
+	tokenSettings.keys = keys
+	tokenSettings.signing = signing
+	return nil
+}
+
+func readJWTKey(path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	key := &jwtKey{id: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("unsupported private key")
+		}
+		key.private = signer
+		key.public = signer.Public()
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key.private = parsed
+		key.public = parsed.Public()
+	case "PUBLIC KEY":
+		key.public, err = x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+
+	switch key.public.(type) {
+	case *rsa.PublicKey:
+		key.method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", key.public)
+	}
+	return key, nil
+}
+
+// GenerateTokenKey creates a private key of the given type and returns it PEM
+// encoded, for a new <kid>.pem file in the key directory.
+func GenerateTokenKey(keyType string) ([]byte, error) {
+	var private any
+	var err error
+	switch keyType {
+	case KeyTypeRSA:
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case KeyTypeEd25519:
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, fmt.Errorf("unknown key type %q, use %s or %s", keyType, KeyTypeRSA, KeyTypeEd25519)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// JWKS returns the public keys tokens are verified with, sorted by kid. It is empty
+// while tokens are signed with HS256 only.
+func JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range tokenSettings.keys {
+		jwk := JSONWebKey{KeyID: key.id, Use: "sig", Algorithm: key.method.Alg()}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	slices.SortFunc(set.Keys, func(a, b JSONWebKey) int { return strings.Compare(a.KeyID, b.KeyID) })
+	return set
+}
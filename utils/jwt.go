@@ -1,54 +1,145 @@
 package utils
 
 import (
-	"os"
+	"crypto/rand"
+	"errors"
+	"slices"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func getKey() string {
-	key := os.Getenv("JWT_KEY")
-	if key == "" {
-		panic("jwt secret not found")
+var tokenSettings struct {
+	// key is the HS256 secret. It signs tokens until LoadTokenKeys sets a signing
+	// key, afterwards it only verifies older tokens.
+	key []byte
+	// keys are the asymmetric keys by kid, signing is the one new tokens are
+	// signed with.
+	keys       map[string]*jwtKey
+	signing    *jwtKey
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+var errNoSigningKey = errors.New("token signing key is not configured")
+
+// challengeTokenTTL is how long users have to enter their second factor after
+// entering their password.
+const challengeTokenTTL = 5 * time.Minute
+
+// InitTokens sets the HS256 secret and the lifetimes of access and refresh tokens,
+// and forgets keys loaded by LoadTokenKeys. key may be empty if LoadTokenKeys is
+// called afterwards.
+func InitTokens(key string, accessTTL time.Duration, refreshTTL time.Duration) {
+	tokenSettings.key = []byte(key)
+	tokenSettings.keys = nil
+	tokenSettings.signing = nil
+	tokenSettings.accessTTL = accessTTL
+	tokenSettings.refreshTTL = refreshTTL
+}
+
+// keyFunc picks the verification key by the kid header. Tokens without one are
+// HS256 tokens. The algorithm must match the key, so a public key can't be used as
+// an HMAC secret.
+func keyFunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if len(tokenSettings.key) == 0 || token.Method != jwt.SigningMethodHS256 {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return tokenSettings.key, nil
+	}
+
+	key, ok := tokenSettings.keys[kid]
+	if !ok || token.Method != key.method {
+		return nil, jwt.ErrTokenUnverifiable
+	}
+	return key.public, nil
+}
+
+// validMethods are the algorithms of the configured keys.
+func validMethods() []string {
+	var methods []string
+	if len(tokenSettings.key) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
 	}
-	return key
+	for _, key := range tokenSettings.keys {
+		if !slices.Contains(methods, key.method.Alg()) {
+			methods = append(methods, key.method.Alg())
+		}
+	}
+	return methods
+}
+
+// RefreshTokenTTL is how long refresh tokens are valid.
+func RefreshTokenTTL() time.Duration {
+	return tokenSettings.refreshTTL
+}
+
+// NewTokenID returns a random identifier for the jti claim.
+func NewTokenID() string {
+	return rand.Text()
 }
 
-func SignPayload(userID int64) (string, error) {
+// SignPayload signs an access token. tokenVersion is the user's current token version;
+// the token stops working once the version is incremented.
+func SignPayload(userID int64, tokenVersion int) (string, error) {
 	claims := jwt.MapClaims{
 		"userID": userID,
-		"exp":    time.Now().Add(3 * time.Hour).Unix(),
+		"exp":    time.Now().Add(tokenSettings.accessTTL).Unix(),
 		"type":   "access",
+		"ver":    tokenVersion,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	return token.SignedString([]byte(getKey()))
+	return signClaims(claims)
 }
 
-func SignRefreshToken(userID int64) (string, error) {
+// SignRefreshToken signs a refresh token for the session identified by jti.
+func SignRefreshToken(userID int64, jti string) (string, error) {
 	claims := jwt.MapClaims{
 		"userID": userID,
-		"exp":    time.Now().Add(7 * 24 * time.Hour).Unix(),
+		"exp":    time.Now().Add(tokenSettings.refreshTTL).Unix(),
 		"type":   "refresh",
+		"jti":    jti,
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return signClaims(claims)
+}
 
-	return token.SignedString([]byte(getKey()))
+// SignChallengeToken signs the token /login returns instead of access tokens when
+// the user has to enter a second factor. Like access tokens it carries the token
+// version, so revoking tokens also cancels pending logins.
+func SignChallengeToken(userID int64, tokenVersion int) (string, error) {
+	claims := jwt.MapClaims{
+		"userID": userID,
+		"exp":    time.Now().Add(challengeTokenTTL).Unix(),
+		"type":   "2fa_challenge",
+		"ver":    tokenVersion,
+	}
+
+	return signClaims(claims)
+}
+
+func signClaims(claims jwt.MapClaims) (string, error) {
+	if signing := tokenSettings.signing; signing != nil {
+		token := jwt.NewWithClaims(signing.method, claims)
+		token.Header["kid"] = signing.id
+		return token.SignedString(signing.private)
+	}
+
+	if len(tokenSettings.key) == 0 {
+		return "", errNoSigningKey
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(tokenSettings.key)
 }
 
 func VerifyPayload(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return []byte(getKey()), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	return jwt.Parse(tokenString, keyFunc, jwt.WithValidMethods(validMethods()))
 }
 
 func VerifyRefreshToken(tokenString string) (*jwt.Token, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
-		return []byte(getKey()), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	token, err := jwt.Parse(tokenString, keyFunc, jwt.WithValidMethods(validMethods()))
 
 	if err != nil {
 		return nil, err
@@ -62,3 +153,45 @@ func VerifyRefreshToken(tokenString string) (*jwt.Token, error) {
 
 	return token, nil
 }
+
+// ParseRefreshToken verifies a refresh token and returns its user ID and session ID.
+func ParseRefreshToken(tokenString string) (int64, string, error) {
+	token, err := VerifyRefreshToken(tokenString)
+	if err != nil {
+		return 0, "", err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return 0, "", jwt.ErrTokenInvalidClaims
+	}
+	userID, ok := claims["userID"].(float64)
+	if !ok {
+		return 0, "", jwt.ErrTokenInvalidClaims
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return 0, "", jwt.ErrTokenInvalidId
+	}
+	return int64(userID), jti, nil
+}
+
+// ParseChallengeToken verifies a challenge token and returns its user ID and token
+// version.
+func ParseChallengeToken(tokenString string) (int64, int, error) {
+	token, err := VerifyPayload(tokenString)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["type"] != "2fa_challenge" {
+		return 0, 0, jwt.ErrTokenInvalidClaims
+	}
+	userID, ok := claims["userID"].(float64)
+	if !ok {
+		return 0, 0, jwt.ErrTokenInvalidClaims
+	}
+	tokenVersion, _ := claims["ver"].(float64)
+	return int64(userID), int(tokenVersion), nil
+}
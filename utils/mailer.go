@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Email is a plain text message to a single recipient.
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers emails.
+type Mailer interface {
+	Send(email Email) error
+}
+
+// SMTPMailer sends emails through an SMTP server. Authentication is only used
+// when a username is set.
+type SMTPMailer struct {
+	addr string
+	from *mail.Address
+	auth smtp.Auth
+}
+
+// NewSMTPMailer sends from the address in from, which may have a display name such
+// as "Plantie <no-reply@example.com>". Only the From header shows the name.
+func NewSMTPMailer(host string, port int, username, password, from string) (*SMTPMailer, error) {
+	address, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address %q: %w", from, err)
+	}
+	mailer := &SMTPMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: address,
+	}
+	if username != "" {
+		mailer.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return mailer, nil
+}
+
+func (m *SMTPMailer) Send(email Email) error {
+	return smtp.SendMail(m.addr, m.auth, m.from.Address, []string{email.To}, formatEmail(m.from.String(), email))
+}
+
+// LogMailer only logs emails, including their body. Useful for development.
+type LogMailer struct{}
+
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+func (m *LogMailer) Send(email Email) error {
+	log.Printf("mailer: to=%s subject=%q\n%s", email.To, email.Subject, email.Body)
+	return nil
+}
+
+// FileMailer writes every email to its own .eml file in a directory, where
+// developers can open them with a mail client.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir, from string) *FileMailer {
+	return &FileMailer{dir: dir, from: from}
+}
+
+func (m *FileMailer) Send(email Email) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+	pattern := fmt.Sprintf("%s-*.eml", time.Now().UTC().Format("20060102T150405"))
+	file, err := os.CreateTemp(m.dir, pattern)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(formatEmail(m.from, email))
+	return err
+}
+
+// RecordingMailer keeps sent emails in memory for tests.
+type RecordingMailer struct {
+	mu     sync.Mutex
+	emails []Email
+}
+
+func NewRecordingMailer() *RecordingMailer {
+	return &RecordingMailer{}
+}
+
+func (m *RecordingMailer) Send(email Email) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.emails = append(m.emails, email)
+	return nil
+}
+
+func (m *RecordingMailer) Emails() []Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Email(nil), m.emails...)
+}
+
+func formatEmail(from string, email Email) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", email.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", email.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(email.Body)
+	return buf.Bytes()
+}
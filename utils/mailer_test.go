@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+// smtpStub accepts one SMTP session and records its commands and message.
+type smtpStub struct {
+	listener net.Listener
+	commands chan string
+}
+
+func newSMTPStub(t *testing.T) *smtpStub {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	stub := &smtpStub{listener: listener, commands: make(chan string, 100)}
+	go stub.serve()
+	return stub
+}
+
+func (s *smtpStub) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	defer close(s.commands)
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 stub")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.commands <- line
+		switch verb := strings.ToUpper(strings.Fields(line + " ")[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 stub")
+		case "DATA":
+			reply("354 go ahead")
+			for {
+				data, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				s.commands <- strings.TrimRight(data, "\r\n")
+				if data == ".\r\n" {
+					break
+				}
+			}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *smtpStub) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestSMTPMailer_SendsFromBareAddress(t *testing.T) {
+	stub := newSMTPStub(t)
+	mailer, err := NewSMTPMailer("127.0.0.1", stub.port(), "", "", "Plantie <no-reply@localhost>")
+	if err != nil {
+		t.Fatalf("NewSMTPMailer failed: %v", err)
+	}
+
+	if err := mailer.Send(Email{To: "user@example.com", Subject: "Hi", Body: "Hello"}); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+
+	var session []string
+	for command := range stub.commands {
+		session = append(session, command)
+	}
+	transcript := strings.Join(session, "\n")
+	if !strings.Contains(transcript, "MAIL FROM:<no-reply@localhost>") {
+		t.Errorf("Expected the bare address as envelope sender, got:\n%s", transcript)
+	}
+	if !strings.Contains(transcript, `From: "Plantie" <no-reply@localhost>`) {
+		t.Errorf("Expected the display name in the From header, got:\n%s", transcript)
+	}
+}
+
+func TestNewSMTPMailer_RejectsInvalidFrom(t *testing.T) {
+	if _, err := NewSMTPMailer("localhost", 25, "", "", "Plantie"); err == nil {
+		t.Error("Expected an error for a from without an address")
+	}
+}
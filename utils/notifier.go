@@ -2,48 +2,245 @@ package utils
 
 import (
 	"context"
-	"os"
+	"errors"
+	"fmt"
+	"log"
+	"plant-reminder/constants"
+	"strings"
+	"sync"
 
 	firebase "firebase.google.com/go"
 	"firebase.google.com/go/messaging"
 	"google.golang.org/api/option"
 )
 
-var client *messaging.Client
+// DeliveryStatus describes the outcome of a single push delivery.
+type DeliveryStatus int
 
-func InitNotifier() error {
-	if client != nil {
+const (
+	DeliverySuccess DeliveryStatus = iota
+	DeliveryInvalidToken
+	DeliveryUnregistered
+	DeliveryQuotaExceeded
+	DeliveryTransientFailure
+	// DeliveryInvalidMessage means FCM rejected the message itself, e.g. an
+	// oversized body. The device is fine, but resending won't help.
+	DeliveryInvalidMessage
+)
+
+func (s DeliveryStatus) String() string {
+	return [...]string{"success", "invalid_token", "unregistered", "quota_exceeded", "transient_failure", "invalid_message"}[s]
+}
+
+type DeliveryResult struct {
+	Status    DeliveryStatus
+	MessageID string
+	Err       error
+}
+
+func (r DeliveryResult) Delivered() bool {
+	return r.Status == DeliverySuccess
+}
+
+// IsPermanentFailure reports whether the token will never accept messages again
+// and should be removed.
+func (r DeliveryResult) IsPermanentFailure() bool {
+	return r.Status == DeliveryInvalidToken || r.Status == DeliveryUnregistered
+}
+
+// IsRetryable reports whether sending the same message again may succeed.
+func (r DeliveryResult) IsRetryable() bool {
+	return r.Status == DeliveryQuotaExceeded || r.Status == DeliveryTransientFailure
+}
+
+// Error returns the delivery error, or nil if the message was delivered.
+func (r DeliveryResult) Error() error {
+	if r.Delivered() {
 		return nil
 	}
+	if r.Err != nil {
+		return r.Err
+	}
+	return errors.New("push delivery failed: " + r.Status.String())
+}
+
+// Notification is the content of a push message.
+type Notification struct {
+	Title string
+	Body  string
+}
+
+var taskMessages = map[constants.TaskType]Notification{
+	constants.TaskWater:     {Title: "Time to water", Body: "Time to water your plant %s"},
+	constants.TaskFertilize: {Title: "Time to fertilize", Body: "Time to fertilize your plant %s"},
+	constants.TaskMist:      {Title: "Time to mist", Body: "Time to mist your plant %s"},
+	constants.TaskRepot:     {Title: "Time to repot", Body: "Time to repot your plant %s"},
+	constants.TaskRotate:    {Title: "Time to rotate", Body: "Time to rotate your plant %s so it grows evenly"},
+	constants.TaskPrune:     {Title: "Time to prune", Body: "Time to prune your plant %s"},
+}
+
+// missedNouns names a task in "you missed N ..." messages, singular and plural.
+var missedNouns = map[constants.TaskType][2]string{
+	constants.TaskWater:     {"watering", "waterings"},
+	constants.TaskFertilize: {"feeding", "feedings"},
+	constants.TaskMist:      {"misting", "mistings"},
+	constants.TaskRepot:     {"repotting", "repottings"},
+	constants.TaskRotate:    {"rotation", "rotations"},
+	constants.TaskPrune:     {"pruning", "prunings"},
+}
+
+// ReminderNotification builds the push message for a reminder task. Custom tasks use
+// their label as the title.
+func ReminderNotification(taskType constants.TaskType, taskLabel string, plantName string) Notification {
+	if taskType == constants.TaskCustom {
+		return Notification{Title: taskLabel, Body: fmt.Sprintf("%s: %s", plantName, taskLabel)}
+	}
+
+	template, ok := taskMessages[taskType]
+	if !ok {
+		template = taskMessages[constants.TaskWater]
+	}
+	return Notification{Title: template.Title, Body: fmt.Sprintf(template.Body, plantName)}
+}
+
+// MissedReminderNotification builds the catch-up message for a reminder whose
+// previous occurrences were missed, e.g. "You missed 2 waterings. Time to water your plant Monstera".
+func MissedReminderNotification(taskType constants.TaskType, taskLabel string, plantName string, missed int) Notification {
+	notification := ReminderNotification(taskType, taskLabel, plantName)
+
+	noun, ok := missedNouns[taskType]
+	if !ok {
+		noun = [2]string{fmt.Sprintf("%q reminder", taskLabel), fmt.Sprintf("%q reminders", taskLabel)}
+	}
+	if missed == 1 {
+		notification.Body = fmt.Sprintf("You missed 1 %s. %s", noun[0], notification.Body)
+	} else {
+		notification.Body = fmt.Sprintf("You missed %d %s. %s", missed, noun[1], notification.Body)
+	}
+	return notification
+}
+
+// Notifier delivers push notifications to a device token.
+type Notifier interface {
+	SendMessage(token string, notification Notification) DeliveryResult
+}
+
+// FCMNotifier sends notifications through Firebase Cloud Messaging.
+type FCMNotifier struct {
+	client *messaging.Client
+}
+
+func NewFCMNotifier(credentialsPath string) (*FCMNotifier, error) {
 	ctx := context.Background()
-	opt := option.WithCredentialsFile(os.Getenv("FIREBASE_PATH"))
+	opt := option.WithCredentialsFile(credentialsPath)
 	app, err := firebase.NewApp(ctx, nil, opt)
+	if err != nil {
+		return nil, err
+	}
 
+	client, err := app.Messaging(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	client, err = app.Messaging(ctx)
+	return &FCMNotifier{client: client}, nil
+}
+
+func (n *FCMNotifier) SendMessage(token string, notification Notification) DeliveryResult {
+	id, err := n.client.Send(context.Background(), buildMessage(token, notification))
 	if err != nil {
-		return err
+		return DeliveryResult{Status: classifyFCMError(err), Err: err}
 	}
+	return DeliveryResult{Status: DeliverySuccess, MessageID: id}
+}
+
+func classifyFCMError(err error) DeliveryStatus {
+	switch {
+	case messaging.IsRegistrationTokenNotRegistered(err):
+		return DeliveryUnregistered
+	case messaging.IsInvalidArgument(err) && isInvalidTokenError(err):
+		return DeliveryInvalidToken
+	case messaging.IsInvalidArgument(err):
+		return DeliveryInvalidMessage
+	case messaging.IsMessageRateExceeded(err):
+		return DeliveryQuotaExceeded
+	default:
+		return DeliveryTransientFailure
+	}
+}
+
+// isInvalidTokenError tells a malformed registration token apart from the other
+// invalid arguments. The SDK reports both as invalid-argument and only the message
+// from FCM names the token.
+func isInvalidTokenError(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "registration token")
+}
+
+// LogNotifier only logs notifications. Useful for running without Firebase credentials.
+type LogNotifier struct{}
 
-	return nil
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
 }
 
-func SendMessage(token string, plantName string) {
-	if client == nil {
-		InitNotifier()
+func (n *LogNotifier) SendMessage(token string, notification Notification) DeliveryResult {
+	log.Printf("notifier: token=%s title=%q body=%q", shortToken(token), notification.Title, notification.Body)
+	return DeliveryResult{Status: DeliverySuccess}
+}
+
+// shortToken keeps the end of a device token, enough to tell devices apart in logs.
+// The whole token would let anyone who reads the logs push to the device.
+func shortToken(token string) string {
+	const visible = 6
+	if len(token) <= visible {
+		return "..."
 	}
+	return "..." + token[len(token)-visible:]
+}
 
-	ctx := context.Background()
+// SentMessage is a notification captured by RecordingNotifier.
+type SentMessage struct {
+	Token        string
+	Notification Notification
+}
+
+// RecordingNotifier keeps sent notifications in memory for tests.
+// Result is returned from every SendMessage call and defaults to success.
+type RecordingNotifier struct {
+	mu       sync.Mutex
+	messages []SentMessage
+	Result   DeliveryResult
+}
+
+func NewRecordingNotifier() *RecordingNotifier {
+	return &RecordingNotifier{}
+}
+
+func (n *RecordingNotifier) SendMessage(token string, notification Notification) DeliveryResult {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, SentMessage{Token: token, Notification: notification})
+	return n.Result
+}
+
+func (n *RecordingNotifier) Messages() []SentMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]SentMessage(nil), n.messages...)
+}
+
+func (n *RecordingNotifier) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = nil
+}
 
-	message := &messaging.Message{
+func buildMessage(token string, notification Notification) *messaging.Message {
+	return &messaging.Message{
 		Token: token,
 		Data: map[string]string{
-			"title": "Hello from Plantie!",
-			"body":  "Time to water your plant " + plantName,
+			"title": notification.Title,
+			"body":  notification.Body,
 		},
 	}
-	client.Send(ctx, message)
 }
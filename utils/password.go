@@ -1,12 +1,48 @@
 package utils
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var passwordCost = 14
+
+// SetPasswordCost sets the bcrypt cost of new password hashes. Existing hashes keep
+// the cost they were created with.
+func SetPasswordCost(cost int) {
+	passwordCost = cost
+}
 
 func HashPassword(password string) (string, error) {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), passwordCost)
 	return string(hash), err
 }
 
 func CheckPassword(hashedPassword string, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// NewSecretToken returns a random token for links sent by email. Store only its
+// HashToken hash.
+func NewSecretToken() string {
+	return rand.Text() + rand.Text()
+}
+
+// APIKeyPrefix starts every API key, which tells them apart from JWTs and makes
+// leaked keys easy to find.
+const APIKeyPrefix = "plantie_"
+
+// NewAPIKey returns a random API key. Store only its HashToken hash.
+func NewAPIKey() string {
+	return APIKeyPrefix + rand.Text()
+}
+
+// HashToken hashes a random token for storage. Unlike passwords, such tokens have
+// enough entropy that a fast hash is safe.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
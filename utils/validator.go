@@ -2,6 +2,7 @@ package utils
 
 import (
 	"plant-reminder/constants"
+	"time"
 
 	"github.com/go-playground/validator"
 )
@@ -10,6 +11,7 @@ var Validate = func() *validator.Validate {
 	validate := validator.New()
 
 	validate.RegisterValidation("validrepeattype", validateRepeatType)
+	validate.RegisterValidation("timezone", validateTimeZone)
 
 	return validate
 }()
@@ -18,9 +20,19 @@ func validateRepeatType(fl validator.FieldLevel) bool {
 	repeatType := fl.Field().Interface().(constants.RepeatType)
 
 	switch repeatType {
-	case constants.RepeatDaily, constants.RepeatWeekly, constants.RepeatMonthly:
+	case constants.RepeatDaily, constants.RepeatWeekly, constants.RepeatMonthly,
+		constants.RepeatEveryNDays, constants.RepeatEveryNWeeks, constants.RepeatCron:
 		return true
 	default:
 		return false
 	}
 }
+
+func validateTimeZone(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}